@@ -0,0 +1,346 @@
+// Package client is a Go client of the cache's HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/lru"
+)
+
+// batchConcurrency is the maximum number of requests of a batch which are sent at once.
+const batchConcurrency = 8
+
+type (
+	// Client is a client of the HTTP API, it's safe for concurrent use.
+	Client struct {
+		baseURL    string
+		hc         *http.Client
+		retries    int
+		backoff    time.Duration
+		maxBackoff time.Duration
+	}
+
+	// Item is a cached value along with its metadata.
+	Item struct {
+		Key   string
+		Value any
+		// ExpiresAt is the time the key expires, it's zero if the key never expires.
+		ExpiresAt time.Time
+		// Stale is true if the value is being refreshed from the store in the background.
+		Stale bool
+	}
+
+	// Error is an error response of the API.
+	Error struct {
+		StatusCode int
+		// Detail is the error's message given by the server.
+		Detail string
+	}
+
+	// getResponse is the response of /get/{key}.
+	getResponse struct {
+		Key       string     `json:"key"`
+		Value     any        `json:"value"`
+		ExpiresAt *time.Time `json:"expires_at"`
+		Stale     bool       `json:"stale"`
+	}
+
+	// setRequest is the request of /set, a nil ttl means the cache's default ttl.
+	setRequest struct {
+		Key   string  `json:"key"`
+		Value any     `json:"value"`
+		TTL   *string `json:"ttl,omitempty"`
+	}
+)
+
+// Error implements error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("client: %s (status %d)", e.Detail, e.StatusCode)
+}
+
+// notFoundDetail is the detail of the server's responses for keys which don't exist.
+const notFoundDetail = "not found"
+
+// Unwrap returns the cache's error of the response, so errors.Is(err, lru.ErrNotFound) holds for 404 responses
+// of keys which don't exist, and errors.Is(err, lru.ErrTooLarge) for 413 responses.
+// Other 404 responses, like those of a proxy which doesn't know the route, aren't ErrNotFound.
+func (e *Error) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound && e.Detail == notFoundDetail:
+		return lru.ErrNotFound
+	case e.StatusCode == http.StatusRequestEntityTooLarge:
+		return lru.ErrTooLarge
+	}
+	return nil
+}
+
+// New returns a new client of the server at the base URL, like http://127.0.0.1:2376.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid base url %q", baseURL)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 64
+
+	c := Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		hc:         &http.Client{Transport: transport},
+		retries:    DefaultRetries,
+		backoff:    DefaultBackoff,
+		maxBackoff: DefaultMaxBackoff,
+	}
+
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return nil, err
+		}
+	}
+
+	return &c, nil
+}
+
+// Get fetches the key, it returns an error which is lru.ErrNotFound if the key doesn't exist.
+func (c *Client) Get(ctx context.Context, key string) (Item, error) {
+	var resp getResponse
+	if err := c.do(ctx, http.MethodGet, "/get/"+url.PathEscape(key), nil, &resp); err != nil {
+		return Item{}, err
+	}
+
+	item := Item{Key: resp.Key, Value: resp.Value, Stale: resp.Stale}
+	if resp.ExpiresAt != nil {
+		item.ExpiresAt = *resp.ExpiresAt
+	}
+	return item, nil
+}
+
+// Set sets the key to the value with the cache's default ttl.
+// The value must be encodable as JSON, it returns an error which is lru.ErrTooLarge if it's larger than the cache's max bytes.
+func (c *Client) Set(ctx context.Context, key string, val any) error {
+	return c.set(ctx, setRequest{Key: key, Value: val})
+}
+
+// SetWithTTL sets the key to the value with the ttl, a zero ttl means the key never expires.
+func (c *Client) SetWithTTL(ctx context.Context, key string, val any, ttl time.Duration) error {
+	if ttl < 0 {
+		return errors.New("ttl cannot be negative")
+	}
+
+	d := ttl.String()
+	return c.set(ctx, setRequest{Key: key, Value: val, TTL: &d})
+}
+
+// set sends the set request.
+func (c *Client) set(ctx context.Context, req setRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, http.MethodPost, "/set", body, nil)
+}
+
+// Delete removes the key, it returns an error which is lru.ErrNotFound if the key doesn't exist.
+// A retried delete may also return it if the first attempt removed the key.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	return c.do(ctx, http.MethodDelete, "/keys/"+url.PathEscape(key), nil, nil)
+}
+
+// Flush removes every key.
+func (c *Client) Flush(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/flush", nil, nil)
+}
+
+// Stats fetches the cache's statistics.
+func (c *Client) Stats(ctx context.Context) (lru.Stats, error) {
+	var stats lru.Stats
+	err := c.do(ctx, http.MethodGet, "/stats", nil, &stats)
+	return stats, err
+}
+
+// GetMany fetches the keys concurrently, the keys which don't exist are left out of the returned items.
+func (c *Client) GetMany(ctx context.Context, keys []string) (map[string]Item, error) {
+	var m sync.Mutex
+	items := make(map[string]Item, len(keys))
+
+	err := c.batch(ctx, len(keys), func(ctx context.Context, i int) error {
+		item, err := c.Get(ctx, keys[i])
+		if errors.Is(err, lru.ErrNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		m.Lock()
+		items[keys[i]] = item
+		m.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// SetMany sets the key-values concurrently with the cache's default ttl.
+func (c *Client) SetMany(ctx context.Context, vals map[string]any) error {
+	keys := make([]string, 0, len(vals))
+	for key := range vals {
+		keys = append(keys, key)
+	}
+
+	return c.batch(ctx, len(keys), func(ctx context.Context, i int) error {
+		return c.Set(ctx, keys[i], vals[keys[i]])
+	})
+}
+
+// DeleteMany removes the keys concurrently, the keys which don't exist are ignored.
+func (c *Client) DeleteMany(ctx context.Context, keys []string) error {
+	return c.batch(ctx, len(keys), func(ctx context.Context, i int) error {
+		if err := c.Delete(ctx, keys[i]); err != nil && !errors.Is(err, lru.ErrNotFound) {
+			return err
+		}
+		return nil
+	})
+}
+
+// batch calls fn for 0 to n-1 concurrently, the first error cancels the other calls and is returned.
+func (c *Client) batch(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, batchConcurrency)
+
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := fn(ctx, i); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	// The context may be done before every call is started.
+	return ctx.Err()
+}
+
+// do sends the request and decodes the response into out unless it's nil, the request is retried if it fails temporarily.
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out any) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		if retry, err = c.send(ctx, method, path, body, out); !retry || attempt == c.retries {
+			return err
+		}
+
+		timer := time.NewTimer(c.backoffOf(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// send sends the request once, it returns true if the request may succeed when it's retried.
+func (c *Client) send(ctx context.Context, method, path string, body []byte, out any) (bool, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, r)
+	if err != nil {
+		return false, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	// The body is read to its end, so the connection can be reused.
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := Error{StatusCode: resp.StatusCode, Detail: http.StatusText(resp.StatusCode)}
+
+		var detail struct {
+			Detail string `json:"detail"`
+		}
+		if json.Unmarshal(respBody, &detail) == nil && detail.Detail != "" {
+			apiErr.Detail = detail.Detail
+		}
+
+		switch resp.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return ctx.Err() == nil, &apiErr
+		}
+		return false, &apiErr
+	}
+
+	if out == nil {
+		return false, nil
+	}
+	return false, json.Unmarshal(respBody, out)
+}
+
+// backoffOf returns how long to wait before the retry which follows the attempt,
+// it's a random duration up to the exponential backoff of the attempt.
+func (c *Client) backoffOf(attempt int) time.Duration {
+	backoff := c.maxBackoff
+	if attempt < 32 && c.backoff<<attempt < c.maxBackoff && c.backoff<<attempt > 0 {
+		backoff = c.backoff << attempt
+	}
+
+	// Half of the backoff is fixed, so retries never follow each other right away.
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/testutil"
+	"github.com/MojtabaArezoomand/lru_cache/lru"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestClient returns a client of a server which serves the handler, the server is closed when the test finishes.
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	c, err := New(testutil.ServeHTTP(t, handler)+"/", append([]Option{WithBackoff(time.Millisecond, 2*time.Millisecond)}, opts...)...)
+	assert.NoError(t, err)
+
+	return c
+}
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		url  string
+		opts []Option
+		err  string
+	}{
+		{url: "http://127.0.0.1:2376"},
+		{url: "https://cache.example.com/", opts: []Option{WithRetries(0), WithHTTPClient(http.DefaultClient)}},
+		{url: "127.0.0.1:2376", err: "first path segment in URL cannot contain colon"},
+		{url: "ftp://127.0.0.1", err: `invalid base url "ftp://127.0.0.1"`},
+		{url: "http://", err: `invalid base url "http://"`},
+		{url: "http://127.0.0.1", opts: []Option{WithHTTPClient(nil)}, err: "http client cannot be nil"},
+		{url: "http://127.0.0.1", opts: []Option{WithRetries(-1)}, err: "retries cannot be negative"},
+		{url: "http://127.0.0.1", opts: []Option{WithBackoff(0, time.Second)}, err: "backoff must be greater than 0"},
+		{url: "http://127.0.0.1", opts: []Option{WithBackoff(time.Second, time.Millisecond)}, err: "max backoff cannot be less than backoff"},
+	}
+
+	for _, tc := range testCases {
+		_, err := New(tc.url, tc.opts...)
+		if tc.err == "" {
+			assert.NoError(t, err, tc.url)
+		} else {
+			assert.ErrorContains(t, err, tc.err, tc.url)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/get/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail": "not found"}`))
+		case "/keys/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 page not found"))
+		case "/set":
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			w.Write([]byte(`{"detail": "value is too large"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("bad request"))
+		}
+	})
+	ctx := context.Background()
+
+	_, err := c.Get(ctx, "missing")
+	assert.Equal(t, &Error{StatusCode: http.StatusNotFound, Detail: "not found"}, err)
+	assert.EqualError(t, err, "client: not found (status 404)")
+	assert.ErrorIs(t, err, lru.ErrNotFound)
+
+	// A 404 of an unknown route isn't a missing key.
+	err = c.Delete(ctx, "missing")
+	assert.Equal(t, &Error{StatusCode: http.StatusNotFound, Detail: "Not Found"}, err)
+	assert.False(t, errors.Is(err, lru.ErrNotFound))
+
+	err = c.Set(ctx, "key", "val")
+	assert.ErrorIs(t, err, lru.ErrTooLarge)
+	assert.False(t, errors.Is(err, lru.ErrNotFound))
+
+	// Responses without a detail are described by their status.
+	err = c.Flush(ctx)
+	assert.Equal(t, &Error{StatusCode: http.StatusBadRequest, Detail: "Bad Request"}, err)
+
+	assert.EqualError(t, c.SetWithTTL(ctx, "key", "val", -time.Second), "ttl cannot be negative")
+	assert.ErrorContains(t, c.Set(ctx, "key", func() {}), "unsupported type")
+}
+
+func TestRetries(t *testing.T) {
+	var calls int64
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// Every third call succeeds.
+		if atomic.AddInt64(&calls, 1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"message": "ok"}`))
+	})
+	ctx := context.Background()
+
+	assert.NoError(t, c.Flush(ctx))
+	assert.Equal(t, int64(3), atomic.LoadInt64(&calls))
+
+	// Requests fail once they run out of retries.
+	c.retries = 1
+	atomic.StoreInt64(&calls, 0)
+	err := c.Flush(ctx)
+	assert.Equal(t, &Error{StatusCode: http.StatusServiceUnavailable, Detail: "Service Unavailable"}, err)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&calls))
+
+	// Other errors aren't retried.
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	atomic.StoreInt64(&calls, 0)
+	assert.Error(t, c.Flush(ctx))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&calls))
+}
+
+func TestRetriesTransportErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	c, err := New(srv.URL, WithRetries(3), WithBackoff(time.Millisecond, time.Millisecond))
+	assert.NoError(t, err)
+	assert.Error(t, c.Flush(context.Background()))
+
+	// A done context stops the retries.
+	c, err = New(srv.URL, WithBackoff(time.Hour, time.Hour))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, c.Flush(ctx), context.DeadlineExceeded)
+}
+
+func TestBackoff(t *testing.T) {
+	c, err := New("http://127.0.0.1", WithBackoff(10*time.Millisecond, 50*time.Millisecond))
+	assert.NoError(t, err)
+
+	for attempt, max := range []time.Duration{10, 20, 40, 50, 50} {
+		max *= time.Millisecond
+		for i := 0; i < 10; i++ {
+			backoff := c.backoffOf(attempt)
+			assert.GreaterOrEqual(t, backoff, max/2)
+			assert.LessOrEqual(t, backoff, max)
+		}
+	}
+
+	// Large attempts don't overflow.
+	assert.LessOrEqual(t, c.backoffOf(100), 50*time.Millisecond)
+}
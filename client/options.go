@@ -0,0 +1,60 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Defaults used when the corresponding option isn't given.
+const (
+	DefaultRetries    = 2
+	DefaultBackoff    = 50 * time.Millisecond
+	DefaultMaxBackoff = time.Second
+)
+
+// Option configures a client created by New.
+type Option func(*Client) error
+
+// WithHTTPClient sets the HTTP client which sends the requests,
+// it defaults to a client which keeps idle connections to the server for reuse.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) error {
+		if hc == nil {
+			return errors.New("http client cannot be nil")
+		}
+
+		c.hc = hc
+		return nil
+	}
+}
+
+// WithRetries sets how many times a request is retried after a transport error or a 502, 503 or 504 response,
+// zero disables retries. It defaults to DefaultRetries.
+func WithRetries(retries int) Option {
+	return func(c *Client) error {
+		if retries < 0 {
+			return errors.New("retries cannot be negative")
+		}
+
+		c.retries = retries
+		return nil
+	}
+}
+
+// WithBackoff sets the backoff between retries, which doubles on each retry up to max, and is randomized.
+// It defaults to DefaultBackoff and DefaultMaxBackoff.
+func WithBackoff(base, max time.Duration) Option {
+	return func(c *Client) error {
+		if base <= 0 {
+			return errors.New("backoff must be greater than 0")
+		}
+		if max < base {
+			return errors.New("max backoff cannot be less than backoff")
+		}
+
+		c.backoff = base
+		c.maxBackoff = max
+		return nil
+	}
+}
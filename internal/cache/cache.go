@@ -1,153 +1,163 @@
 package cache
 
 import (
-	"context"
+	"encoding/json"
 	"errors"
-	"sync"
+	"time"
 
 	"github.com/MojtabaArezoomand/lru_cache/internal/config"
-	linkedlist "github.com/MojtabaArezoomand/lru_cache/internal/linked_list"
+	"github.com/MojtabaArezoomand/lru_cache/lru"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type (
-	// Cache is the LRU cache struct
-	Cache struct {
-		m        sync.Mutex
-		list     *linkedlist.DoublyLinkedList
-		storage  map[string]*linkedlist.Node
-		capacity uint64
-	}
+	// Cache is the LRU cache used by the server, it maps string keys to any JSON value.
+	Cache = lru.Cache[string, any]
 
-	// getResult is the struct for sending cache's Get result using channels.
-	getResult struct {
-		val any
-		err error
-	}
+	// Item is a cached value along with its metadata.
+	Item = lru.Item[any]
+
+	// Stats is a snapshot of the cache's counters.
+	Stats = lru.Stats
+
+	// Option configures a cache created by NewCache.
+	Option = lru.Option[string, any]
 )
 
 // Errors.
 var (
-	ErrNotFound error = errors.New("not found")
+	ErrNotFound error = lru.ErrNotFound
+	ErrTooLarge error = lru.ErrTooLarge
 )
 
-// NewCache returns a new cache.
-func NewCache() *Cache {
+// NewCache returns a new cache configured by the given options.
+func NewCache(opts ...Option) (*Cache, error) {
+	return lru.New(opts...)
+}
+
+// EnvOptions returns the cache options configured by environment variables.
+func EnvOptions() ([]Option, error) {
 	var cfg config.CacheConfig
 	if err := cleanenv.ReadEnv(&cfg); err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	cache := Cache{
-		list:     linkedlist.NewDoublyLinkedList(),
-		storage:  make(map[string]*linkedlist.Node),
-		capacity: cfg.CacheCapacity.ToUint64(),
+	if cfg.SLRUProtectedRatio < 0 || cfg.SLRUProtectedRatio > 1 {
+		return nil, errors.New("CACHE_SLRU_PROTECTED_RATIO must be between 0 and 1")
 	}
 
-	return &cache
-}
+	opts := []Option{
+		WithCapacity(cfg.CacheCapacity.ToUint64()),
+		WithShards(cfg.Shards),
+		WithPolicy(lru.PolicyName(cfg.Policy)),
+		WithDefaultTTL(cfg.DefaultTTL),
+		WithRefreshAfter(cfg.RefreshAfter),
+		WithCleanupInterval(cfg.CleanupInterval),
+	}
 
-// Get fetches the key from the cache.
-func (c *Cache) Get(ctx context.Context, key string) (any, error) {
-	if ctx == nil {
-		panic("Context cannot be nil.")
+	if lru.PolicyName(cfg.Policy) == lru.PolicySLRU {
+		ratio := cfg.SLRUProtectedRatio
+		opts = append(opts, WithPolicyFunc(func(capacity uint64) lru.Policy[string] {
+			return lru.NewSLRU[string](capacity, ratio)
+		}))
 	}
 
-	getChan := make(chan getResult, 1)
+	if cfg.MaxBytes > 0 {
+		opts = append(opts, WithMaxBytes(cfg.MaxBytes), WithSizer(JSONSizer))
+	}
 
-	go func() {
-		val, err := c.get(key)
-		getChan <- getResult{val: val, err: err}
-	}()
+	if cfg.StoreDir != "" {
+		store, err := lru.NewFileStore[any](cfg.StoreDir)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithStore(store))
 
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case res := <-getChan:
-		return res.val, res.err
+		if cfg.WriteBehind > 0 {
+			opts = append(opts, WithWriteBehind(cfg.WriteBehind))
+		}
 	}
-}
 
-// get fetches the key from storage.
-func (c *Cache) get(key string) (any, error) {
-	c.m.Lock()
-	defer c.m.Unlock()
+	return opts, nil
+}
 
-	if node, ok := c.storage[key]; !ok {
-		return nil, ErrNotFound
-	} else {
-		c.list.MoveToBack(node)
-		val := node.GetVal()
-		return val, nil
+// JSONSizer returns the length of the key plus the length of the JSON encoded value.
+// Values which can't be encoded only cost the key's length.
+func JSONSizer(key string, val any) uint64 {
+	b, err := json.Marshal(val)
+	if err != nil {
+		return uint64(len(key))
 	}
+
+	return uint64(len(key) + len(b))
 }
 
-// Set sets or overwrites the key-value to cache.
-func (c *Cache) Set(ctx context.Context, key string, val any) error {
-	if ctx == nil {
-		panic("Context cannot be nil.")
-	}
+// WithCapacity sets the maximum number of keys the cache holds.
+func WithCapacity(capacity uint64) Option {
+	return lru.WithCapacity[string, any](capacity)
+}
 
-	done := make(chan bool, 1)
+// WithShards splits the cache into the given number of shards, each of them with its own lock.
+func WithShards(shards uint64) Option {
+	return lru.WithShards[string, any](shards)
+}
 
-	go func() {
-		c.set(key, val)
-		done <- true
-	}()
+// WithPolicy sets the eviction policy.
+func WithPolicy(name lru.PolicyName) Option {
+	return lru.WithPolicy[string, any](name)
+}
 
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-done:
-		return nil
-	}
+// WithPolicyFunc sets a function which creates a custom eviction policy for a cache with the given capacity.
+func WithPolicyFunc(newPolicy func(capacity uint64) lru.Policy[string]) Option {
+	return lru.WithPolicyFunc[string, any](newPolicy)
 }
 
-// set sets or overwrites the key-value to cache.
-func (c *Cache) set(key string, val any) {
-	c.m.Lock()
-	defer c.m.Unlock()
+// WithMaxBytes bounds the total cost of the stored keys, as computed by the sizer given by WithSizer.
+func WithMaxBytes(maxBytes uint64) Option {
+	return lru.WithMaxBytes[string, any](maxBytes)
+}
 
-	if node, ok := c.storage[key]; ok {
-		node.SetVal(val)
-		c.list.MoveToBack(node)
-	} else {
-		if c.capacity == c.list.Size() {
-			key := c.list.RemoveHead()
-			delete(c.storage, key)
-		}
+// WithSizer sets the function which computes the cost of a key-value in bytes.
+func WithSizer(sizer func(key string, val any) uint64) Option {
+	return lru.WithSizer(sizer)
+}
 
-		node := c.list.AddToBack(key, val)
-		c.storage[key] = node
-	}
+// WithDefaultTTL sets the ttl of keys stored without an explicit ttl.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return lru.WithDefaultTTL[string, any](ttl)
 }
 
-// Flush resets the cache.
-func (c *Cache) Flush(ctx context.Context) error {
-	if ctx == nil {
-		panic("Context cannot be nil.")
-	}
+// WithRefreshAfter sets how old keys get before they are served stale and reloaded from the store in the background.
+func WithRefreshAfter(refreshAfter time.Duration) Option {
+	return lru.WithRefreshAfter[string, any](refreshAfter)
+}
 
-	done := make(chan bool, 1)
+// WithCleanupInterval sets how often expired keys are removed in the background.
+func WithCleanupInterval(interval time.Duration) Option {
+	return lru.WithCleanupInterval[string, any](interval)
+}
 
-	go func() {
-		c.flush()
-		done <- true
-	}()
+// WithStore sets the backing store which the cache reads through and writes through to.
+func WithStore(store lru.Store[string, any]) Option {
+	return lru.WithStore[string, any](store)
+}
 
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-done:
-		return nil
-	}
+// WithWriteBehind saves the writes to the store every interval instead of on every write.
+func WithWriteBehind(interval time.Duration) Option {
+	return lru.WithWriteBehind[string, any](interval)
 }
 
-// flush resets the cache.
-func (c *Cache) flush() {
-	c.m.Lock()
-	defer c.m.Unlock()
+// WithLog records the writes of the cache in the append-only log, the log is replayed by NewCache.
+func WithLog(log *lru.Log[string, any]) Option {
+	return lru.WithLog(log)
+}
+
+// WithOnEvict sets a callback which is called with every value removed from the cache and the reason it was removed.
+func WithOnEvict(onEvict func(key string, val any, reason lru.EvictionReason)) Option {
+	return lru.WithOnEvict(onEvict)
+}
 
-	c.storage = make(map[string]*linkedlist.Node)
-	c.list = linkedlist.NewDoublyLinkedList()
+// WithClock sets the function used to get the current time.
+func WithClock(now func() time.Time) Option {
+	return lru.WithClock[string, any](now)
 }
@@ -2,169 +2,154 @@ package cache
 
 import (
 	"context"
-	"os"
-	"sync"
 	"testing"
+	"time"
 
+	"github.com/MojtabaArezoomand/lru_cache/lru"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewCache(t *testing.T) {
-	cache := NewCache()
-
-	assert.NotNil(t, cache.list)
-	assert.NotNil(t, cache.storage)
-	assert.EqualValues(t, 2048, cache.capacity)
-
-	os.Setenv("CACHE_CAPACITY", "0")
-
-	assert.Panics(t, func() {
-		NewCache()
-	})
-
-	os.Setenv("CACHE_CAPACITY", "2048")
-}
-
-func TestGetSet(t *testing.T) {
-	cache := NewCache()
-	cache.capacity = 3
-
-	cache.set("first", 1)
-
-	assert.EqualValues(t, 1, cache.list.Size())
-	assert.EqualValues(t, 1, len(cache.storage))
-
-	res, err := cache.get("first")
+	var evicted []string
+
+	now := time.Unix(1000, 0)
+
+	cache, err := NewCache(
+		WithCapacity(1),
+		WithPolicy(lru.PolicyFIFO),
+		WithDefaultTTL(time.Second),
+		WithCleanupInterval(0),
+		WithOnEvict(func(key string, val any, reason lru.EvictionReason) { evicted = append(evicted, key) }),
+		WithClock(func() time.Time { return now }),
+	)
 	assert.NoError(t, err)
+	defer cache.Close()
 
-	assert.Equal(t, 1, res)
-
-	// Overwriting the first key
-	cache.set("first", 2)
+	ctx := context.Background()
 
-	assert.EqualValues(t, 1, cache.list.Size())
-	assert.EqualValues(t, 1, len(cache.storage))
+	assert.NoError(t, cache.Set(ctx, "key", []any{1, "val"}))
 
-	res, err = cache.get("first")
+	item, err := cache.GetItem(ctx, "key")
 	assert.NoError(t, err)
+	assert.Equal(t, Item{Value: []any{1, "val"}, ExpiresAt: now.Add(time.Second), Version: 1}, item)
 
-	assert.Equal(t, 2, res)
-
-	cache.set("second", 4)
-	cache.set("third", 5)
-
-	assert.EqualValues(t, 3, cache.list.Size())
-	assert.EqualValues(t, 3, len(cache.storage))
-
-	assert.Equal(t, cache.list.Tail(), cache.storage["third"])
-	assert.Equal(t, cache.list.Head(), cache.storage["first"])
+	assert.NoError(t, cache.Set(ctx, "other", 1))
+	assert.Equal(t, []string{"key"}, evicted)
 
-	// Exceeding the capacity
-	cache.set("fourth", 10)
-
-	assert.EqualValues(t, 3, cache.list.Size())
-	assert.EqualValues(t, 3, len(cache.storage))
-
-	assert.Equal(t, cache.list.Tail(), cache.storage["fourth"])
-	assert.Equal(t, cache.list.Head(), cache.storage["second"])
-
-	_, err = cache.get("first")
-	assert.ErrorIs(t, ErrNotFound, err)
-
-	_, err = cache.get("third")
-	assert.NoError(t, err)
+	_, err = cache.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrNotFound)
 
-	assert.Equal(t, cache.list.Tail(), cache.storage["third"])
-	assert.Equal(t, cache.list.Head(), cache.storage["second"])
+	_, err = NewCache(WithCapacity(0))
+	assert.Error(t, err)
 }
 
-func TestGetSetDataRace(t *testing.T) {
-	cache := NewCache()
-
-	cache.set("first", 1)
-
-	var wg sync.WaitGroup
-	wg.Add(100)
-
-	for i := 0; i < 100; i++ {
-		go func() {
-			defer wg.Done()
-
-			_, err := cache.get("first")
-			assert.NoError(t, err)
-			cache.set("first", 1)
-		}()
-	}
-
-	wg.Wait()
+func TestJSONSizer(t *testing.T) {
+	assert.EqualValues(t, 3+len(`[1,"val"]`), JSONSizer("key", []any{1, "val"}))
+	assert.EqualValues(t, 3+len(`null`), JSONSizer("key", nil))
+	assert.EqualValues(t, 3, JSONSizer("key", make(chan int)))
 }
 
-func TestTestGetSetContext(t *testing.T) {
-	cache := NewCache()
-
-	ctx1, cancel := context.WithCancel(context.Background())
-	cancel()
-
-	err := cache.Set(ctx1, "1", 1)
-
-	assert.ErrorIs(t, context.Canceled, err)
-
-	_, err = cache.Get(ctx1, "1")
-
-	assert.ErrorIs(t, context.Canceled, err)
-
-	ctx2, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	err = cache.Set(ctx2, "1", 1)
-
-	assert.NoError(t, err)
-
-	v, err := cache.Get(ctx2, "1")
-
+func TestMaxBytes(t *testing.T) {
+	cache, err := NewCache(WithMaxBytes(10), WithSizer(JSONSizer), WithCleanupInterval(0))
 	assert.NoError(t, err)
-	assert.Equal(t, 1, v)
-}
+	defer cache.Close()
 
-func TestGetSetPanics(t *testing.T) {
-	cache := NewCache()
+	ctx := context.Background()
 
-	assert.Panics(t, func() {
-		cache.Set(nil, "", 1)
-	})
+	assert.NoError(t, cache.Set(ctx, "a", "val"))
+	assert.NoError(t, cache.Set(ctx, "b", "val"))
 
-	assert.Panics(t, func() {
-		cache.Get(nil, "")
-	})
-}
-
-func TestFlush(t *testing.T) {
-	cache := NewCache()
-
-	cache.set("first_key", 1)
-
-	cache.flush()
-
-	_, err := cache.get("first_key")
+	_, err = cache.Get(ctx, "a")
 	assert.ErrorIs(t, err, ErrNotFound)
-	assert.Zero(t, cache.list.Size())
-	assert.Empty(t, cache.storage)
-}
-
-func TestFlushContext(t *testing.T) {
-	cache := NewCache()
 
-	ctx, cancel := context.WithCancel(context.Background())
-
-	err := cache.Flush(ctx)
-	assert.NoError(t, err)
-
-	cancel()
+	assert.ErrorIs(t, cache.Set(ctx, "c", "too long value"), ErrTooLarge)
+}
 
-	err = cache.Flush(ctx)
-	assert.ErrorIs(t, err, ctx.Err())
+func TestEnvOptions(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name string
+		env  map[string]string
+		// err is the error of EnvOptions, newErr is the error of creating a cache with its options.
+		err    string
+		newErr string
+		check  func(t *testing.T, cache *Cache)
+	}{
+		{name: "defaults"},
+		{
+			name: "default_ttl",
+			env:  map[string]string{"CACHE_DEFAULT_TTL": "1h"},
+			check: func(t *testing.T, cache *Cache) {
+				assert.NoError(t, cache.Set(ctx, "key", 1))
+
+				item, err := cache.GetItem(ctx, "key")
+				assert.NoError(t, err)
+				assert.WithinDuration(t, time.Now().Add(time.Hour), item.ExpiresAt, time.Second)
+			},
+		},
+		{name: "invalid_default_ttl", env: map[string]string{"CACHE_DEFAULT_TTL": "invalid_value"}, err: `time: invalid duration "invalid_value"`},
+		{name: "negative_refresh_after", env: map[string]string{"CACHE_REFRESH_AFTER": "-1s"}, newErr: "refresh after cannot be negative"},
+		{
+			name: "max_bytes",
+			env:  map[string]string{"CACHE_MAX_BYTES": "10"},
+			check: func(t *testing.T, cache *Cache) {
+				assert.ErrorIs(t, cache.Set(ctx, "key", "too long value"), ErrTooLarge)
+			},
+		},
+		{name: "unknown_policy", env: map[string]string{"CACHE_POLICY": "unknown"}, newErr: `unknown policy "unknown"`},
+		{name: "slru", env: map[string]string{"CACHE_POLICY": "slru", "CACHE_SLRU_PROTECTED_RATIO": "0.5"}},
+		{name: "invalid_slru_protected_ratio", env: map[string]string{"CACHE_SLRU_PROTECTED_RATIO": "1.5"}, err: "CACHE_SLRU_PROTECTED_RATIO must be between 0 and 1"},
+		{name: "shards", env: map[string]string{"CACHE_SHARDS": "4"}},
+		{name: "zero_shards", env: map[string]string{"CACHE_SHARDS": "0"}, newErr: "shards must be greater than 0"},
+		{
+			name: "store_dir",
+			env:  map[string]string{"CACHE_STORE_DIR": t.TempDir(), "CACHE_WRITE_BEHIND_INTERVAL": "1h"},
+			check: func(t *testing.T, cache *Cache) {
+				assert.NoError(t, cache.Set(ctx, "key", "val"))
+				cache.Close()
+
+				// Closing the cache saves its pending writes to the store, where a new cache finds them.
+				opts, err := EnvOptions()
+				require.NoError(t, err)
+
+				reopened, err := NewCache(opts...)
+				require.NoError(t, err)
+				defer reopened.Close()
+
+				val, err := reopened.Get(ctx, "key")
+				assert.NoError(t, err)
+				assert.Equal(t, "val", val)
+			},
+		},
+		{name: "zero_capacity", env: map[string]string{"CACHE_CAPACITY": "0"}, err: "CACHE_CAPACITY must be greater than 0"},
+	}
 
-	assert.Panics(t, func() {
-		cache.Flush(nil)
-	})
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for key, val := range tc.env {
+				t.Setenv(key, val)
+			}
+
+			opts, err := EnvOptions()
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			cache, err := NewCache(opts...)
+			if tc.newErr != "" {
+				assert.EqualError(t, err, tc.newErr)
+				return
+			}
+			require.NoError(t, err)
+			defer cache.Close()
+
+			if tc.check != nil {
+				tc.check(t, cache)
+			}
+		})
+	}
 }
@@ -36,11 +36,35 @@ func (u NonZeroUint64) ToUint64() uint64 {
 
 // CacheConfig is the cache config struct.
 type CacheConfig struct {
-	CacheCapacity NonZeroUint64 `env:"CACHE_CAPACITY" env-default:"2048"`
+	CacheCapacity      NonZeroUint64 `env:"CACHE_CAPACITY" env-default:"2048"`
+	MaxBytes           uint64        `env:"CACHE_MAX_BYTES" env-default:"0"`
+	Shards             uint64        `env:"CACHE_SHARDS" env-default:"1"`
+	Policy             string        `env:"CACHE_POLICY" env-default:"lru"`
+	SLRUProtectedRatio float64       `env:"CACHE_SLRU_PROTECTED_RATIO" env-default:"0.8"`
+	DefaultTTL         time.Duration `env:"CACHE_DEFAULT_TTL" env-default:"0"`
+	RefreshAfter       time.Duration `env:"CACHE_REFRESH_AFTER" env-default:"0"`
+	CleanupInterval    time.Duration `env:"CACHE_CLEANUP_INTERVAL" env-default:"1m"`
+	StoreDir           string        `env:"CACHE_STORE_DIR"`
+	WriteBehind        time.Duration `env:"CACHE_WRITE_BEHIND_INTERVAL" env-default:"0"`
+}
+
+// SnapshotConfig is the config of the cache's snapshots.
+type SnapshotConfig struct {
+	Path     string        `env:"CACHE_SNAPSHOT_PATH"`
+	Interval time.Duration `env:"CACHE_SNAPSHOT_INTERVAL" env-default:"0"`
+}
+
+// LogConfig is the config of the cache's append-only log.
+type LogConfig struct {
+	Path  string `env:"CACHE_LOG_PATH"`
+	Fsync string `env:"CACHE_LOG_FSYNC" env-default:"everysec"`
 }
 
 type ServerConfig struct {
-	Address      string        `env:"SERVER_ADDRESS" env-default:"127.0.0.1:2376"`
-	WriteTimeout time.Duration `env:"SERVER_WRITE_TIMEOUT" env-default:"1s"`
-	ReadTimeout  time.Duration `env:"SERVER_READ_TIMEOUT" env-default:"1s"`
+	Address          string        `env:"SERVER_ADDRESS" env-default:"127.0.0.1:2376"`
+	WriteTimeout     time.Duration `env:"SERVER_WRITE_TIMEOUT" env-default:"1s"`
+	ReadTimeout      time.Duration `env:"SERVER_READ_TIMEOUT" env-default:"1s"`
+	RESPAddress      string        `env:"SERVER_RESP_ADDRESS"`
+	MemcachedAddress string        `env:"SERVER_MEMCACHED_ADDRESS"`
+	BinaryAddress    string        `env:"SERVER_BINARY_ADDRESS"`
 }
@@ -2,60 +2,70 @@ package linkedlist
 
 type (
 	// Node is the linked list's node.
-	Node struct {
-		key   string
-		value any
-		next  *Node
-		prev  *Node
+	Node[K comparable, V any] struct {
+		key   K
+		value V
+		next  *Node[K, V]
+		prev  *Node[K, V]
 	}
 
 	// LinkedList is a linked list of nodes.
-	DoublyLinkedList struct {
+	DoublyLinkedList[K comparable, V any] struct {
 		size uint64
-		head *Node
-		tail *Node
+		head *Node[K, V]
+		tail *Node[K, V]
 	}
 )
 
 // New returns a new linked list.
-func NewDoublyLinkedList() *DoublyLinkedList {
-	return &DoublyLinkedList{}
+func NewDoublyLinkedList[K comparable, V any]() *DoublyLinkedList[K, V] {
+	return &DoublyLinkedList[K, V]{}
 }
 
 // GetVal returns the node's value.
-func (n *Node) GetVal() any {
+func (n *Node[K, V]) GetVal() V {
 	return n.value
 }
 
 // SetVal sets node's value.
-func (n *Node) SetVal(val any) {
+func (n *Node[K, V]) SetVal(val V) {
 	n.value = val
 }
 
 // GetKey returns the node's key.
-func (n *Node) GetKey() string {
+func (n *Node[K, V]) GetKey() K {
 	return n.key
 }
 
+// Next returns the node after this one, it's nil for the tail.
+func (n *Node[K, V]) Next() *Node[K, V] {
+	return n.next
+}
+
+// Prev returns the node before this one, it's nil for the head.
+func (n *Node[K, V]) Prev() *Node[K, V] {
+	return n.prev
+}
+
 // Size returns the size of linked list.
-func (l *DoublyLinkedList) Size() uint64 {
+func (l *DoublyLinkedList[K, V]) Size() uint64 {
 	return l.size
 }
 
 // Head returns the linked list's head.
-func (l *DoublyLinkedList) Head() *Node {
+func (l *DoublyLinkedList[K, V]) Head() *Node[K, V] {
 	return l.head
 }
 
 // Tail returns the linked list's tail.
-func (l *DoublyLinkedList) Tail() *Node {
+func (l *DoublyLinkedList[K, V]) Tail() *Node[K, V] {
 	return l.tail
 }
 
 // AddToBack adds a new value to the back of the linked list and returns the added node.
-func (l *DoublyLinkedList) AddToBack(key string, val any) *Node {
+func (l *DoublyLinkedList[K, V]) AddToBack(key K, val V) *Node[K, V] {
 	if l.Size() == 0 {
-		node := Node{key: key, value: val, next: nil, prev: nil}
+		node := Node[K, V]{key: key, value: val, next: nil, prev: nil}
 		l.head = &node
 		l.tail = &node
 		l.size++
@@ -63,7 +73,7 @@ func (l *DoublyLinkedList) AddToBack(key string, val any) *Node {
 		return &node
 	}
 
-	node := Node{key: key, value: val, next: nil, prev: l.tail}
+	node := Node[K, V]{key: key, value: val, next: nil, prev: l.tail}
 	l.tail.next = &node
 	l.tail = &node
 	l.size++
@@ -71,8 +81,36 @@ func (l *DoublyLinkedList) AddToBack(key string, val any) *Node {
 	return &node
 }
 
+// AddToFront adds a new value to the front of the linked list and returns the added node.
+func (l *DoublyLinkedList[K, V]) AddToFront(key K, val V) *Node[K, V] {
+	if l.Size() == 0 {
+		return l.AddToBack(key, val)
+	}
+
+	node := Node[K, V]{key: key, value: val, next: l.head, prev: nil}
+	l.head.prev = &node
+	l.head = &node
+	l.size++
+
+	return &node
+}
+
+// InsertAfter adds a new value right after the mark node and returns the added node.
+func (l *DoublyLinkedList[K, V]) InsertAfter(mark *Node[K, V], key K, val V) *Node[K, V] {
+	if mark == l.tail {
+		return l.AddToBack(key, val)
+	}
+
+	node := Node[K, V]{key: key, value: val, next: mark.next, prev: mark}
+	mark.next.prev = &node
+	mark.next = &node
+	l.size++
+
+	return &node
+}
+
 // MoveToBack moves a node to the back of the linked list.
-func (l *DoublyLinkedList) MoveToBack(node *Node) {
+func (l *DoublyLinkedList[K, V]) MoveToBack(node *Node[K, V]) {
 	if l.Size() == 0 {
 		panic("List is empty")
 	}
@@ -93,12 +131,36 @@ func (l *DoublyLinkedList) MoveToBack(node *Node) {
 		next.prev = prev
 		node.next = nil
 		node.prev = l.tail
+		l.tail.next = node
 		l.tail = node
 	}
 }
 
+// Remove unlinks an arbitrary node from the linked list.
+func (l *DoublyLinkedList[K, V]) Remove(node *Node[K, V]) {
+	if l.Size() == 0 {
+		panic("List is empty")
+	}
+
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else {
+		l.head = node.next
+	}
+
+	if node.next != nil {
+		node.next.prev = node.prev
+	} else {
+		l.tail = node.prev
+	}
+
+	node.next = nil
+	node.prev = nil
+	l.size--
+}
+
 // RemoveHead removes the head node.
-func (l *DoublyLinkedList) RemoveHead() string {
+func (l *DoublyLinkedList[K, V]) RemoveHead() K {
 	if l.Size() == 0 {
 		panic("List is empty")
 	}
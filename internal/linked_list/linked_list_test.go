@@ -8,7 +8,7 @@ import (
 )
 
 func TestNewDoublyLinkedList(t *testing.T) {
-	l := NewDoublyLinkedList()
+	l := NewDoublyLinkedList[string, int]()
 
 	assert.Nil(t, l.head)
 	assert.Nil(t, l.tail)
@@ -16,16 +16,16 @@ func TestNewDoublyLinkedList(t *testing.T) {
 }
 
 func TestSize(t *testing.T) {
-	l := NewDoublyLinkedList()
+	l := NewDoublyLinkedList[string, int]()
 
 	l.size = 600
 
 	assert.EqualValues(t, l.size, l.Size())
 }
 func TestHead(t *testing.T) {
-	l := NewDoublyLinkedList()
+	l := NewDoublyLinkedList[string, int]()
 
-	node := new(Node)
+	node := new(Node[string, int])
 
 	l.head = node
 
@@ -33,9 +33,9 @@ func TestHead(t *testing.T) {
 }
 
 func TestTail(t *testing.T) {
-	l := NewDoublyLinkedList()
+	l := NewDoublyLinkedList[string, int]()
 
-	node := new(Node)
+	node := new(Node[string, int])
 
 	l.tail = node
 
@@ -43,7 +43,7 @@ func TestTail(t *testing.T) {
 }
 
 func TestAddToBack(t *testing.T) {
-	l := NewDoublyLinkedList()
+	l := NewDoublyLinkedList[string, int]()
 
 	vals := []int{1, 2, 3, 4}
 
@@ -73,7 +73,7 @@ func TestAddToBack(t *testing.T) {
 }
 
 func TestMoveToBack(t *testing.T) {
-	l := NewDoublyLinkedList()
+	l := NewDoublyLinkedList[string, int]()
 
 	head := l.AddToBack("1", 1)
 	tail := l.AddToBack("2", 2)
@@ -103,7 +103,7 @@ func TestMoveToBack(t *testing.T) {
 	assert.Equal(t, middle, l.Tail())
 	assert.Equal(t, head, l.Head())
 
-	l = NewDoublyLinkedList()
+	l = NewDoublyLinkedList[string, int]()
 
 	assert.Panics(t, func() {
 		l.MoveToBack(tail)
@@ -111,7 +111,7 @@ func TestMoveToBack(t *testing.T) {
 }
 
 func TestRemoveHead(t *testing.T) {
-	l := NewDoublyLinkedList()
+	l := NewDoublyLinkedList[string, int]()
 
 	l.AddToBack("1", 1)
 	h2 := l.AddToBack("2", 2)
@@ -139,7 +139,7 @@ func TestRemoveHead(t *testing.T) {
 }
 
 func TestNodeMethods(t *testing.T) {
-	node := Node{key: "key", value: "value", next: nil, prev: nil}
+	node := Node[string, string]{key: "key", value: "value", next: nil, prev: nil}
 
 	assert.Equal(t, "key", node.GetKey())
 	assert.Equal(t, "value", node.GetVal())
@@ -147,4 +147,119 @@ func TestNodeMethods(t *testing.T) {
 	node.SetVal("changed")
 
 	assert.Equal(t, "changed", node.GetVal())
+	assert.Nil(t, node.Next())
+
+	next := Node[string, string]{key: "next"}
+	node.next = &next
+
+	assert.Equal(t, &next, node.Next())
+	assert.Nil(t, node.Prev())
+
+	next.prev = &node
+
+	assert.Equal(t, &node, next.Prev())
+}
+
+func TestMoveToBackKeepsLinks(t *testing.T) {
+	l := NewDoublyLinkedList[string, int]()
+
+	first := l.AddToBack("1", 1)
+	middle := l.AddToBack("2", 2)
+	last := l.AddToBack("3", 3)
+
+	l.MoveToBack(middle)
+
+	assert.Equal(t, middle, last.next)
+	assert.Equal(t, last, middle.prev)
+	assert.Equal(t, last, first.next)
+
+	assert.Equal(t, "1", l.RemoveHead())
+	assert.Equal(t, "3", l.RemoveHead())
+	assert.Equal(t, "2", l.RemoveHead())
+}
+
+func TestRemove(t *testing.T) {
+	l := NewDoublyLinkedList[string, int]()
+
+	n1 := l.AddToBack("1", 1)
+	n2 := l.AddToBack("2", 2)
+	n3 := l.AddToBack("3", 3)
+	n4 := l.AddToBack("4", 4)
+
+	// Removing a middle node
+	l.Remove(n2)
+
+	assert.EqualValues(t, 3, l.Size())
+	assert.Equal(t, n3, n1.next)
+	assert.Equal(t, n1, n3.prev)
+	assert.Nil(t, n2.next)
+	assert.Nil(t, n2.prev)
+
+	// Removing the head
+	l.Remove(n1)
+
+	assert.EqualValues(t, 2, l.Size())
+	assert.Equal(t, n3, l.Head())
+	assert.Nil(t, n3.prev)
+
+	// Removing the tail
+	l.Remove(n4)
+
+	assert.EqualValues(t, 1, l.Size())
+	assert.Equal(t, n3, l.Tail())
+	assert.Nil(t, n3.next)
+
+	l.Remove(n3)
+
+	assert.Zero(t, l.Size())
+	assert.Nil(t, l.Head())
+	assert.Nil(t, l.Tail())
+
+	assert.Panics(t, func() {
+		l.Remove(n3)
+	})
+}
+
+func TestAddToFront(t *testing.T) {
+	l := NewDoublyLinkedList[string, int]()
+
+	n1 := l.AddToFront("1", 1)
+
+	assert.Equal(t, n1, l.Head())
+	assert.Equal(t, n1, l.Tail())
+	assert.EqualValues(t, 1, l.Size())
+
+	n2 := l.AddToFront("2", 2)
+
+	assert.Equal(t, n2, l.Head())
+	assert.Equal(t, n1, l.Tail())
+	assert.Equal(t, n1, n2.next)
+	assert.Equal(t, n2, n1.prev)
+	assert.EqualValues(t, 2, l.Size())
+
+	assert.Equal(t, "2", l.RemoveHead())
+	assert.Equal(t, "1", l.RemoveHead())
+}
+
+func TestInsertAfter(t *testing.T) {
+	l := NewDoublyLinkedList[string, int]()
+
+	n1 := l.AddToBack("1", 1)
+	n3 := l.InsertAfter(n1, "3", 3)
+
+	assert.Equal(t, n3, l.Tail())
+	assert.EqualValues(t, 2, l.Size())
+
+	n2 := l.InsertAfter(n1, "2", 2)
+
+	assert.Equal(t, n2, n1.next)
+	assert.Equal(t, n1, n2.prev)
+	assert.Equal(t, n3, n2.next)
+	assert.Equal(t, n2, n3.prev)
+	assert.Equal(t, n3, l.Tail())
+	assert.EqualValues(t, 3, l.Size())
+
+	assert.Equal(t, "1", l.RemoveHead())
+	assert.Equal(t, "2", l.RemoveHead())
+	assert.Equal(t, "3", l.RemoveHead())
 }
@@ -0,0 +1,463 @@
+package memcache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
+	"github.com/MojtabaArezoomand/lru_cache/lru"
+)
+
+// Limits of the protocol, they match memcached's defaults.
+const (
+	maxKeyLen  = 250
+	maxItemLen = 1024 * 1024
+	// maxRelativeExptime is the largest exptime which is a number of seconds, larger ones are unix timestamps.
+	maxRelativeExptime = 60 * 60 * 24 * 30
+)
+
+// version is the memcached version reported to clients.
+const version = "1.6.0"
+
+// Replies.
+const (
+	errClientFormat = "CLIENT_ERROR bad command line format"
+	errTooLarge     = "SERVER_ERROR object too large for cache"
+)
+
+// errBadChunk is returned when a data block doesn't end where its length says, the connection is closed after it.
+var errBadChunk = errors.New("CLIENT_ERROR bad data chunk")
+
+// flagged is a value which is set with non-zero flags, values without flags are stored as plain strings.
+// It's encoded as its data, so the other APIs see it as a string.
+type flagged struct {
+	data  string
+	flags uint32
+}
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (v flagged) MarshalText() ([]byte, error) {
+	return []byte(v.data), nil
+}
+
+// encode returns the data and flags of a cached value.
+// Values set by the other APIs have no flags, and the ones which aren't strings are sent as JSON.
+func encode(val any) (string, uint32) {
+	switch v := val.(type) {
+	case flagged:
+		return v.data, v.flags
+	case string:
+		return v, 0
+	}
+
+	b, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprint(val), 0
+	}
+	return string(b), 0
+}
+
+// decode returns the value which is stored for the data and flags.
+func decode(data string, flags uint32) any {
+	if flags == 0 {
+		return data
+	}
+	return flagged{data: data, flags: flags}
+}
+
+// parseExptime converts an exptime to a ttl, zero means the key never expires.
+// Negative exptimes and timestamps before now are a ttl which has passed by the time the key is read.
+func parseExptime(arg string, now time.Time) (time.Duration, bool) {
+	n, err := strconv.ParseInt(arg, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	var ttl time.Duration
+	switch {
+	case n == 0:
+		return 0, true
+	case n > maxRelativeExptime:
+		ttl = time.Unix(n, 0).Sub(now)
+	default:
+		ttl = time.Duration(n) * time.Second
+	}
+
+	if ttl <= 0 {
+		ttl = time.Nanosecond
+	}
+	return ttl, true
+}
+
+// validKey reports whether the key can be used by the protocol.
+func validKey(key string) bool {
+	return len(key) > 0 && len(key) <= maxKeyLen
+}
+
+// noreply strips the trailing noreply argument, it returns true if there was one.
+func noreply(args []string) ([]string, bool) {
+	if len(args) > 0 && args[len(args)-1] == "noreply" {
+		return args[:len(args)-1], true
+	}
+	return args, false
+}
+
+// exec runs the command of the line and writes its reply.
+// It returns an error if the connection can't be used anymore.
+func (s *session) exec(args []string) error {
+	switch args[0] {
+	case "get":
+		s.get(args, false)
+	case "gets":
+		s.get(args, true)
+	case "set", "add", "replace", "cas":
+		return s.store(args)
+	case "delete":
+		s.delete(args)
+	case "incr", "decr":
+		s.incr(args)
+	case "touch":
+		s.touch(args)
+	case "flush_all":
+		s.flushAll(args)
+	case "stats":
+		s.stats(args)
+	case "version":
+		s.reply("VERSION " + version)
+	case "quit":
+		s.quit = true
+	default:
+		s.reply("ERROR")
+	}
+
+	return nil
+}
+
+// reply writes a line of reply.
+func (s *session) reply(line string) {
+	s.w.WriteString(line)
+	s.w.WriteString("\r\n")
+}
+
+// cacheError writes the reply of an unexpected cache error.
+func (s *session) cacheError(err error) {
+	if errors.Is(err, cache.ErrTooLarge) {
+		s.reply(errTooLarge)
+		return
+	}
+	s.reply("SERVER_ERROR " + err.Error())
+}
+
+// get handles get <key>* and gets <key>*, gets adds the version of each key as its cas unique.
+func (s *session) get(args []string, cas bool) {
+	if len(args) < 2 {
+		s.reply("ERROR")
+		return
+	}
+
+	for _, key := range args[1:] {
+		if !validKey(key) {
+			s.reply(errClientFormat)
+			return
+		}
+	}
+
+	for _, key := range args[1:] {
+		item, err := s.cache.GetItem(s.ctx, key)
+		if errors.Is(err, cache.ErrNotFound) {
+			continue
+		} else if err != nil {
+			s.cacheError(err)
+			return
+		}
+
+		data, flags := encode(item.Value)
+		if cas {
+			fmt.Fprintf(s.w, "VALUE %s %d %d %d\r\n", key, flags, len(data), item.Version)
+		} else {
+			fmt.Fprintf(s.w, "VALUE %s %d %d\r\n", key, flags, len(data))
+		}
+		s.w.WriteString(data)
+		s.w.WriteString("\r\n")
+	}
+
+	s.reply("END")
+}
+
+// store handles set, add, replace and cas, which are followed by a data block:
+// <command> <key> <flags> <exptime> <bytes> [<cas unique>] [noreply]
+func (s *session) store(args []string) error {
+	args, quiet := noreply(args)
+
+	want := 5
+	if args[0] == "cas" {
+		want = 6
+	}
+	if len(args) != want {
+		s.reply("ERROR")
+		return nil
+	}
+
+	key := args[1]
+	flags, err := strconv.ParseUint(args[2], 10, 32)
+	ttl, ok := parseExptime(args[3], s.cache.Now())
+	size, serr := strconv.Atoi(args[4])
+	if !validKey(key) || err != nil || !ok || serr != nil || size < 0 {
+		s.reply(errClientFormat)
+		return nil
+	}
+
+	var version uint64
+	if args[0] == "cas" {
+		if version, err = strconv.ParseUint(args[5], 10, 64); err != nil {
+			s.reply(errClientFormat)
+			return nil
+		}
+	}
+
+	// A data block which is too large is skipped, so the connection can still be used.
+	if size > maxItemLen {
+		if _, err := s.r.Discard(size + 2); err != nil {
+			return err
+		}
+		s.reply(errTooLarge)
+		return nil
+	}
+
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(s.r, data); err != nil {
+		return err
+	}
+	if string(data[size:]) != "\r\n" {
+		s.reply(errBadChunk.Error())
+		return errBadChunk
+	}
+
+	val := decode(string(data[:size]), uint32(flags))
+
+	var stored bool
+	switch args[0] {
+	case "set":
+		stored, err = s.cache.SetWithTTLIf(s.ctx, key, val, ttl, lru.SetAlways)
+	case "add":
+		stored, err = s.cache.SetWithTTLIf(s.ctx, key, val, ttl, lru.SetIfAbsent)
+	case "replace":
+		stored, err = s.cache.SetWithTTLIf(s.ctx, key, val, ttl, lru.SetIfPresent)
+	case "cas":
+		stored, err = s.cache.CompareAndSwap(s.ctx, key, val, ttl, version)
+	}
+
+	if quiet {
+		return nil
+	}
+
+	switch {
+	case args[0] == "cas" && errors.Is(err, cache.ErrNotFound):
+		s.reply("NOT_FOUND")
+	case err != nil:
+		s.cacheError(err)
+	case stored:
+		s.reply("STORED")
+	case args[0] == "cas":
+		s.reply("EXISTS")
+	default:
+		s.reply("NOT_STORED")
+	}
+
+	return nil
+}
+
+// delete handles delete <key> [noreply].
+func (s *session) delete(args []string) {
+	args, quiet := noreply(args)
+	if len(args) != 2 || !validKey(args[1]) {
+		s.reply(errClientFormat)
+		return
+	}
+
+	err := s.cache.Delete(s.ctx, args[1])
+	if quiet {
+		return
+	}
+
+	if errors.Is(err, cache.ErrNotFound) {
+		s.reply("NOT_FOUND")
+	} else if err != nil {
+		s.cacheError(err)
+	} else {
+		s.reply("DELETED")
+	}
+}
+
+// incr handles incr <key> <value> [noreply] and decr <key> <value> [noreply].
+// Increments wrap around at 2^64 and decrements stop at 0, the key keeps its flags and expiration time.
+func (s *session) incr(args []string) {
+	args, quiet := noreply(args)
+	if len(args) != 3 || !validKey(args[1]) {
+		s.reply(errClientFormat)
+		return
+	}
+
+	key := args[1]
+	delta, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil {
+		s.reply("CLIENT_ERROR invalid numeric delta argument")
+		return
+	}
+
+	// The key is swapped only if it's not set meanwhile, otherwise it's read again.
+	for {
+		item, err := s.cache.GetItem(s.ctx, key)
+		if errors.Is(err, cache.ErrNotFound) {
+			s.reply("NOT_FOUND")
+			return
+		} else if err != nil {
+			s.cacheError(err)
+			return
+		}
+
+		data, flags := encode(item.Value)
+		n, err := strconv.ParseUint(data, 10, 64)
+		if err != nil {
+			s.reply("CLIENT_ERROR cannot increment or decrement non-numeric value")
+			return
+		}
+
+		if args[0] == "incr" {
+			n += delta
+		} else if n < delta {
+			n = 0
+		} else {
+			n -= delta
+		}
+
+		var ttl time.Duration
+		if !item.ExpiresAt.IsZero() {
+			if ttl = item.ExpiresAt.Sub(s.cache.Now()); ttl <= 0 {
+				s.reply("NOT_FOUND")
+				return
+			}
+		}
+
+		data = strconv.FormatUint(n, 10)
+		ok, err := s.cache.CompareAndSwap(s.ctx, key, decode(data, flags), ttl, item.Version)
+		if errors.Is(err, cache.ErrNotFound) {
+			s.reply("NOT_FOUND")
+			return
+		} else if err != nil {
+			s.cacheError(err)
+			return
+		} else if !ok {
+			continue
+		}
+
+		if !quiet {
+			s.reply(data)
+		}
+		return
+	}
+}
+
+// touch handles touch <key> <exptime> [noreply].
+func (s *session) touch(args []string) {
+	args, quiet := noreply(args)
+	if len(args) != 3 || !validKey(args[1]) {
+		s.reply(errClientFormat)
+		return
+	}
+
+	ttl, ok := parseExptime(args[2], s.cache.Now())
+	if !ok {
+		s.reply("CLIENT_ERROR invalid exptime argument")
+		return
+	}
+
+	err := s.cache.Expire(s.ctx, args[1], ttl)
+	if quiet {
+		return
+	}
+
+	if errors.Is(err, cache.ErrNotFound) {
+		s.reply("NOT_FOUND")
+	} else if err != nil {
+		s.cacheError(err)
+	} else {
+		s.reply("TOUCHED")
+	}
+}
+
+// flushAll handles flush_all [delay] [noreply], a delay flushes the cache once it passes.
+func (s *session) flushAll(args []string) {
+	args, quiet := noreply(args)
+	if len(args) > 2 {
+		s.reply(errClientFormat)
+		return
+	}
+
+	var delay int64
+	if len(args) == 2 {
+		var err error
+		if delay, err = strconv.ParseInt(args[1], 10, 32); err != nil || delay < 0 {
+			s.reply(errClientFormat)
+			return
+		}
+	}
+
+	// A flush replaces the delayed flush which is pending.
+	var err error
+	if delay > 0 {
+		s.server.flushAfter(time.Duration(delay) * time.Second)
+	} else {
+		s.server.flushAfter(0)
+		err = s.cache.Flush(s.ctx)
+	}
+
+	if quiet {
+		return
+	}
+
+	if err != nil {
+		s.cacheError(err)
+	} else {
+		s.reply("OK")
+	}
+}
+
+// stats handles stats, only the general statistics are supported.
+func (s *session) stats(args []string) {
+	if len(args) > 1 {
+		s.reply("ERROR")
+		return
+	}
+
+	stats := s.cache.Stats()
+	now := time.Now()
+
+	for _, stat := range []struct {
+		name  string
+		value any
+	}{
+		{name: "pid", value: os.Getpid()},
+		{name: "uptime", value: int64(now.Sub(s.server.started).Seconds())},
+		{name: "time", value: now.Unix()},
+		{name: "version", value: version},
+		{name: "curr_connections", value: s.server.Connections()},
+		{name: "total_connections", value: s.server.TotalConnections()},
+		{name: "curr_items", value: stats.Size},
+		{name: "total_items", value: stats.Sets},
+		{name: "bytes", value: stats.Bytes},
+		{name: "get_hits", value: stats.Hits},
+		{name: "get_misses", value: stats.Misses},
+		{name: "evictions", value: stats.Evictions},
+		{name: "expired_unfetched", value: stats.Expirations},
+	} {
+		fmt.Fprintf(s.w, "STAT %s %v\r\n", stat.name, stat.value)
+	}
+
+	s.reply("END")
+}
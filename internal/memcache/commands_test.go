@@ -0,0 +1,271 @@
+package memcache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
+	"github.com/MojtabaArezoomand/lru_cache/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// testSession is a session whose replies are written to a buffer.
+type testSession struct {
+	*session
+	out *bytes.Buffer
+}
+
+// newTestSession returns a new session of a new cache which is closed when the test finishes.
+func newTestSession(t *testing.T, opts ...cache.Option) testSession {
+	c := testutil.NewCache(t, opts...)
+
+	var out bytes.Buffer
+	return testSession{
+		session: &session{server: NewServer(c), cache: c, ctx: context.Background(), w: bufio.NewWriter(&out)},
+		out:     &out,
+	}
+}
+
+// run runs the command of the first line, the other lines are its data block, and returns its reply.
+func (s testSession) run(lines ...string) string {
+	s.r = bufio.NewReader(strings.NewReader(strings.Join(lines, "\r\n") + "\r\n"))
+
+	line, _ := s.r.ReadString('\n')
+	if err := s.exec(strings.Fields(line)); err != nil {
+		s.reply("closed")
+	}
+	s.w.Flush()
+
+	reply := s.out.String()
+	s.out.Reset()
+	return reply
+}
+
+func TestStorage(t *testing.T) {
+	s := newTestSession(t)
+
+	assert.Equal(t, "END\r\n", s.run("get key"))
+	assert.Equal(t, "STORED\r\n", s.run("set key 0 0 3", "val"))
+	assert.Equal(t, "VALUE key 0 3\r\nval\r\nEND\r\n", s.run("get key"))
+
+	// Flags are returned as they are set, and other APIs see the data as a string.
+	assert.Equal(t, "STORED\r\n", s.run("set flagged 42 0 4", "data"))
+	assert.Equal(t, "VALUE flagged 42 4\r\ndata\r\nEND\r\n", s.run("get flagged"))
+	assert.Equal(t, "VALUE key 0 3\r\nval\r\nVALUE flagged 42 4\r\ndata\r\nEND\r\n", s.run("get key missing flagged"))
+
+	// Values set over HTTP are returned as JSON.
+	assert.NoError(t, s.cache.Set(context.Background(), "json", []any{1.0, "val"}))
+	assert.Equal(t, "VALUE json 0 9\r\n[1,\"val\"]\r\nEND\r\n", s.run("get json"))
+
+	assert.Equal(t, "NOT_STORED\r\n", s.run("add key 0 0 5", "other"))
+	assert.Equal(t, "STORED\r\n", s.run("add new 0 0 3", "val"))
+	assert.Equal(t, "NOT_STORED\r\n", s.run("replace missing 0 0 3", "val"))
+	assert.Equal(t, "STORED\r\n", s.run("replace key 0 0 5", "other"))
+	assert.Equal(t, "VALUE key 0 5\r\nother\r\nEND\r\n", s.run("get key"))
+
+	// Empty values can be set.
+	assert.Equal(t, "STORED\r\n", s.run("set empty 0 0 0", ""))
+	assert.Equal(t, "VALUE empty 0 0\r\n\r\nEND\r\n", s.run("get empty"))
+
+	// noreply suppresses the reply.
+	assert.Equal(t, "", s.run("set quiet 0 0 3 noreply", "val"))
+	assert.Equal(t, "VALUE quiet 0 3\r\nval\r\nEND\r\n", s.run("get quiet"))
+
+	testCases := []struct {
+		lines []string
+		reply string
+	}{
+		{lines: []string{"set key 0 0"}, reply: "ERROR\r\n"},
+		{lines: []string{"set key x 0 3", "val"}, reply: "CLIENT_ERROR bad command line format\r\n"},
+		{lines: []string{"set key 0 x 3", "val"}, reply: "CLIENT_ERROR bad command line format\r\n"},
+		{lines: []string{"set key 0 0 -1", "val"}, reply: "CLIENT_ERROR bad command line format\r\n"},
+		{lines: []string{"set " + strings.Repeat("k", 251) + " 0 0 3", "val"}, reply: "CLIENT_ERROR bad command line format\r\n"},
+		{lines: []string{"set key 0 0 2", "val"}, reply: "CLIENT_ERROR bad data chunk\r\nclosed\r\n"},
+		{lines: []string{fmt.Sprintf("set key 0 0 %d", maxItemLen+1), strings.Repeat("v", maxItemLen+1)}, reply: "SERVER_ERROR object too large for cache\r\n"},
+		{lines: []string{"get"}, reply: "ERROR\r\n"},
+		{lines: []string{"unknown"}, reply: "ERROR\r\n"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.reply, s.run(tc.lines...), tc.lines[0])
+	}
+}
+
+func TestStorageTooLarge(t *testing.T) {
+	s := newTestSession(t, cache.WithMaxBytes(16), cache.WithSizer(cache.JSONSizer))
+
+	assert.Equal(t, "SERVER_ERROR object too large for cache\r\n", s.run("set key 0 0 32", strings.Repeat("v", 32)))
+}
+
+func TestExptime(t *testing.T) {
+	s := newTestSession(t)
+
+	testCases := []struct {
+		exptime string
+		ttl     time.Duration
+	}{
+		{exptime: "0"},
+		{exptime: "100", ttl: 100 * time.Second},
+		{exptime: "2592000", ttl: 30 * 24 * time.Hour},
+		{exptime: fmt.Sprint(time.Now().Add(time.Hour).Unix()), ttl: time.Hour},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, "STORED\r\n", s.run("set key 0 "+tc.exptime+" 3", "val"))
+
+		item, err := s.cache.GetItem(context.Background(), "key")
+		assert.NoError(t, err)
+		if tc.ttl == 0 {
+			assert.True(t, item.ExpiresAt.IsZero(), tc.exptime)
+		} else {
+			assert.WithinDuration(t, time.Now().Add(tc.ttl), item.ExpiresAt, 2*time.Second, tc.exptime)
+		}
+	}
+
+	// Negative exptimes and timestamps in the past expire the key right away.
+	for _, exptime := range []string{"-1", "2592001"} {
+		assert.Equal(t, "STORED\r\n", s.run("set key 0 "+exptime+" 3", "val"))
+		time.Sleep(time.Millisecond)
+		assert.Equal(t, "END\r\n", s.run("get key"), exptime)
+	}
+}
+
+func TestExptimeClock(t *testing.T) {
+	now := time.Unix(1000000000, 0)
+	s := newTestSession(t, cache.WithClock(func() time.Time { return now }))
+
+	// Timestamps are relative to the cache's clock.
+	exptime := fmt.Sprint(now.Add(time.Hour).Unix())
+	assert.Equal(t, "STORED\r\n", s.run("set key 0 "+exptime+" 2", "10"))
+	assert.Equal(t, "11\r\n", s.run("incr key 1"))
+
+	item, err := s.cache.GetItem(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), item.ExpiresAt)
+}
+
+func TestCas(t *testing.T) {
+	s := newTestSession(t)
+
+	assert.Equal(t, "NOT_FOUND\r\n", s.run("cas key 0 0 3 1", "val"))
+	assert.Equal(t, "STORED\r\n", s.run("set key 0 0 3", "val"))
+
+	item, err := s.cache.GetItem(context.Background(), "key")
+	assert.NoError(t, err)
+	version := item.Version
+	assert.Equal(t, fmt.Sprintf("VALUE key 0 3 %d\r\nval\r\nEND\r\n", version), s.run("gets key"))
+
+	assert.Equal(t, "EXISTS\r\n", s.run(fmt.Sprintf("cas key 0 0 3 %d", version+1), "new"))
+	assert.Equal(t, "STORED\r\n", s.run(fmt.Sprintf("cas key 5 0 3 %d", version), "new"))
+	assert.Equal(t, "EXISTS\r\n", s.run(fmt.Sprintf("cas key 0 0 3 %d", version), "old"))
+	assert.Equal(t, fmt.Sprintf("VALUE key 5 3 %d\r\nnew\r\nEND\r\n", version+1), s.run("gets key"))
+
+	assert.Equal(t, "CLIENT_ERROR bad command line format\r\n", s.run("cas key 0 0 3 x", "val"))
+}
+
+func TestDelete(t *testing.T) {
+	s := newTestSession(t)
+
+	assert.Equal(t, "NOT_FOUND\r\n", s.run("delete key"))
+	assert.Equal(t, "STORED\r\n", s.run("set key 0 0 3", "val"))
+	assert.Equal(t, "DELETED\r\n", s.run("delete key"))
+	assert.Equal(t, "END\r\n", s.run("get key"))
+	assert.Equal(t, "", s.run("delete key noreply"))
+	assert.Equal(t, "CLIENT_ERROR bad command line format\r\n", s.run("delete key 0"))
+}
+
+func TestIncr(t *testing.T) {
+	s := newTestSession(t)
+
+	assert.Equal(t, "NOT_FOUND\r\n", s.run("incr key 1"))
+	assert.Equal(t, "STORED\r\n", s.run("set key 7 100 2", "10"))
+	assert.Equal(t, "15\r\n", s.run("incr key 5"))
+	assert.Equal(t, "12\r\n", s.run("decr key 3"))
+	assert.Equal(t, "0\r\n", s.run("decr key 20"))
+	assert.Equal(t, "", s.run("incr key 3 noreply"))
+
+	// The key keeps its flags and expiration time.
+	assert.Equal(t, "VALUE key 7 1\r\n3\r\nEND\r\n", s.run("get key"))
+	item, err := s.cache.GetItem(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(100*time.Second), item.ExpiresAt, 2*time.Second)
+
+	// Increments wrap around at 2^64.
+	assert.Equal(t, "STORED\r\n", s.run("set max 0 0 20", "18446744073709551615"))
+	assert.Equal(t, "1\r\n", s.run("incr max 2"))
+
+	assert.Equal(t, "STORED\r\n", s.run("set text 0 0 3", "val"))
+	assert.Equal(t, "CLIENT_ERROR cannot increment or decrement non-numeric value\r\n", s.run("incr text 1"))
+	assert.Equal(t, "CLIENT_ERROR invalid numeric delta argument\r\n", s.run("incr key -1"))
+	assert.Equal(t, "CLIENT_ERROR bad command line format\r\n", s.run("incr key"))
+}
+
+func TestTouch(t *testing.T) {
+	s := newTestSession(t)
+
+	assert.Equal(t, "NOT_FOUND\r\n", s.run("touch key 10"))
+	assert.Equal(t, "STORED\r\n", s.run("set key 0 0 3", "val"))
+	assert.Equal(t, "TOUCHED\r\n", s.run("touch key 10"))
+
+	item, err := s.cache.GetItem(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(10*time.Second), item.ExpiresAt, 2*time.Second)
+
+	assert.Equal(t, "TOUCHED\r\n", s.run("touch key 0"))
+	item, err = s.cache.GetItem(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.True(t, item.ExpiresAt.IsZero())
+
+	assert.Equal(t, "CLIENT_ERROR invalid exptime argument\r\n", s.run("touch key x"))
+}
+
+func TestFlushAll(t *testing.T) {
+	s := newTestSession(t)
+
+	assert.Equal(t, "STORED\r\n", s.run("set key 0 0 3", "val"))
+	assert.Equal(t, "OK\r\n", s.run("flush_all"))
+	assert.Equal(t, "END\r\n", s.run("get key"))
+
+	// A delayed flush runs once the delay passes.
+	assert.Equal(t, "STORED\r\n", s.run("set key 0 0 3", "val"))
+	assert.Equal(t, "OK\r\n", s.run("flush_all 1"))
+	assert.Equal(t, "VALUE key 0 3\r\nval\r\nEND\r\n", s.run("get key"))
+	assert.Eventually(t, func() bool { return s.cache.Stats().Size == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "", s.run("flush_all noreply"))
+	assert.Equal(t, "CLIENT_ERROR bad command line format\r\n", s.run("flush_all x"))
+
+	// A delayed flush replaces the pending one, and closing the server cancels it.
+	assert.Equal(t, "OK\r\n", s.run("flush_all 60"))
+	pending := s.server.flush
+	assert.Equal(t, "OK\r\n", s.run("flush_all 120"))
+	assert.False(t, pending.Stop())
+
+	pending = s.server.flush
+	assert.NoError(t, s.server.Close())
+	assert.False(t, pending.Stop())
+	assert.Nil(t, s.server.flush)
+}
+
+func TestStats(t *testing.T) {
+	s := newTestSession(t)
+
+	assert.Equal(t, "STORED\r\n", s.run("set key 0 0 3", "val"))
+	s.run("get key")
+	s.run("get missing")
+
+	reply := s.run("stats")
+	assert.True(t, strings.HasSuffix(reply, "END\r\n"))
+	assert.Contains(t, reply, "STAT version "+version+"\r\n")
+	assert.Contains(t, reply, "STAT curr_items 1\r\n")
+	assert.Contains(t, reply, "STAT get_hits 1\r\n")
+	assert.Contains(t, reply, "STAT get_misses 1\r\n")
+
+	assert.Equal(t, "ERROR\r\n", s.run("stats items"))
+	assert.Equal(t, "VERSION "+version+"\r\n", s.run("version"))
+}
@@ -0,0 +1,118 @@
+// Package memcache serves the cache over the memcached text protocol, so memcached clients can use it.
+package memcache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
+	"github.com/MojtabaArezoomand/lru_cache/internal/netserver"
+)
+
+// maxLineLen is the maximum length of a command line, longer lines close the connection.
+const maxLineLen = 2048
+
+// ErrServerClosed is returned by Serve once the server is closed.
+var ErrServerClosed = netserver.ErrServerClosed
+
+type (
+	// Server serves the cache to memcached clients.
+	Server struct {
+		*netserver.Server
+		cache   *cache.Cache
+		started time.Time
+
+		m sync.Mutex
+		// flush is the timer of the pending flush_all with a delay.
+		flush *time.Timer
+	}
+
+	// session is the state of a client's connection.
+	session struct {
+		server *Server
+		cache  *cache.Cache
+		ctx    context.Context
+		r      *bufio.Reader
+		w      *bufio.Writer
+		// quit is set once the connection must be closed.
+		quit bool
+	}
+)
+
+// NewServer returns a new server of the cache.
+func NewServer(c *cache.Cache) *Server {
+	s := &Server{cache: c, started: time.Now()}
+	s.Server = netserver.New(s.serveConn)
+	return s
+}
+
+// Close closes the server like netserver.Server.Close does, and cancels the pending flush_all.
+func (s *Server) Close() error {
+	err := s.Server.Close()
+	s.flushAfter(0)
+	return err
+}
+
+// flushAfter flushes the cache once the delay passes, in place of the pending flush.
+// A zero delay only cancels the pending flush.
+func (s *Server) flushAfter(delay time.Duration) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.flush != nil {
+		s.flush.Stop()
+		s.flush = nil
+	}
+
+	if delay > 0 {
+		c := s.cache
+		s.flush = time.AfterFunc(delay, func() {
+			_ = c.Flush(context.Background())
+		})
+	}
+}
+
+// serveConn runs the commands of the connection until it's closed.
+// Replies are flushed once there is no pipelined command left to read.
+func (s *Server) serveConn(ctx context.Context, conn net.Conn, id int64) {
+	sess := session{
+		server: s,
+		cache:  s.cache,
+		ctx:    ctx,
+		r:      bufio.NewReaderSize(conn, maxLineLen),
+		w:      bufio.NewWriter(conn),
+	}
+
+	for {
+		line, err := sess.r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			sess.reply("CLIENT_ERROR line too long")
+			sess.w.Flush()
+			return
+		} else if err != nil {
+			return
+		}
+
+		if args := strings.Fields(string(line)); len(args) > 0 {
+			if err := sess.exec(args); err != nil {
+				sess.w.Flush()
+				if !errors.Is(err, errBadChunk) {
+					log.Println("error in reading memcached data block, reason:", err)
+				}
+				return
+			}
+		}
+
+		if sess.r.Buffered() == 0 || sess.quit {
+			if err := sess.w.Flush(); err != nil || sess.quit {
+				return
+			}
+		}
+	}
+}
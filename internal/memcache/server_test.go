@@ -0,0 +1,54 @@
+package memcache
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	addr := testutil.Serve(t, NewServer(testutil.NewCache(t)))
+	conn, r := testutil.Dial(t, addr)
+
+	// Pipelined commands are answered in order, data blocks may contain line endings.
+	_, err := conn.Write([]byte("set key 3 0 5\r\nv\r\nal\r\n\r\nget key\r\ndelete missing\r\n"))
+	assert.NoError(t, err)
+
+	want := "STORED\r\nVALUE key 3 5\r\nv\r\nal\r\nEND\r\nNOT_FOUND\r\n"
+	assert.Equal(t, want, testutil.ReadN(t, r, len(want)))
+
+	// Another client sees the same cache.
+	other, otherR := testutil.Dial(t, addr)
+	_, err = other.Write([]byte("get key\nquit\r\n"))
+	assert.NoError(t, err)
+
+	rest, err := io.ReadAll(otherR)
+	assert.NoError(t, err)
+	assert.Equal(t, "VALUE key 3 5\r\nv\r\nal\r\nEND\r\n", string(rest))
+}
+
+func TestServerBadInput(t *testing.T) {
+	addr := testutil.Serve(t, NewServer(testutil.NewCache(t)))
+
+	testCases := []struct {
+		input string
+		reply string
+	}{
+		{input: "set key 0 0 1\r\nval\r\n", reply: "CLIENT_ERROR bad data chunk\r\n"},
+		{input: "get " + strings.Repeat("k", maxLineLen) + "\r\n", reply: "CLIENT_ERROR line too long\r\n"},
+	}
+
+	for _, tc := range testCases {
+		conn, r := testutil.Dial(t, addr)
+		_, err := conn.Write([]byte(tc.input))
+		assert.NoError(t, err)
+
+		// The connection is closed after the error.
+		assert.Equal(t, tc.reply, testutil.ReadN(t, r, len(tc.reply)))
+		_, err = r.ReadByte()
+		assert.Error(t, err)
+	}
+}
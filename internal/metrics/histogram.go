@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the default histogram buckets, tailored to measure request latencies in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type (
+	// HistogramVec is a histogram partitioned by label values.
+	HistogramVec struct {
+		name    string
+		help    string
+		buckets []float64
+		labels  []string
+
+		m          sync.Mutex
+		histograms map[string]*histogram
+	}
+
+	// histogram is a single histogram of HistogramVec.
+	histogram struct {
+		labelValues []string
+		// counts are the non-cumulative counts of each bucket, the last one is the +Inf bucket.
+		counts []uint64
+		sum    float64
+		count  uint64
+	}
+)
+
+// NewHistogramVec returns a new histogram partitioned by the given label names.
+// The buckets are the sorted upper bounds, the +Inf bucket is always added.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	b := make([]float64, len(buckets))
+	copy(b, buckets)
+	sort.Float64s(b)
+
+	return &HistogramVec{
+		name:       name,
+		help:       help,
+		buckets:    b,
+		labels:     labels,
+		histograms: make(map[string]*histogram),
+	}
+}
+
+// Observe adds a value to the histogram of the label values.
+// The label values must be in the same order as the label names.
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	if len(labelValues) != len(h.labels) {
+		panic("Number of label values doesn't match the number of labels.")
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	hist, ok := h.histograms[key]
+	if !ok {
+		values := make([]string, len(labelValues))
+		copy(values, labelValues)
+
+		hist = &histogram{labelValues: values, counts: make([]uint64, len(h.buckets)+1)}
+		h.histograms[key] = hist
+	}
+
+	hist.counts[sort.SearchFloat64s(h.buckets, value)]++
+	hist.sum += value
+	hist.count++
+}
+
+// Collect implements Collector interface.
+func (h *HistogramVec) Collect(w *Writer) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	w.Family(h.name, h.help, Histogram)
+
+	keys := make([]string, 0, len(h.histograms))
+	for key := range h.histograms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		hist := h.histograms[key]
+
+		labels := make([]Label, len(h.labels), len(h.labels)+1)
+		for i, name := range h.labels {
+			labels[i] = Label{Name: name, Value: hist.labelValues[i]}
+		}
+
+		var cumulative uint64
+		for i, count := range hist.counts {
+			cumulative += count
+
+			le := math.Inf(1)
+			if i < len(h.buckets) {
+				le = h.buckets[i]
+			}
+
+			w.Sample(h.name+"_bucket", float64(cumulative), append(labels, Label{Name: "le", Value: formatFloat(le)})...)
+		}
+
+		w.Sample(h.name+"_sum", hist.sum, labels...)
+		w.Sample(h.name+"_count", float64(hist.count), labels...)
+	}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramVec(t *testing.T) {
+	h := NewHistogramVec("latency_seconds", "Latency.", []float64{1, 0.1}, "route")
+
+	var w Writer
+	h.Collect(&w)
+
+	assert.Equal(t, "# HELP latency_seconds Latency.\n# TYPE latency_seconds histogram\n", w.buf.String())
+
+	h.Observe(0.05, "/b")
+	h.Observe(0.1, "/b")
+	h.Observe(0.5, "/b")
+	h.Observe(2, "/b")
+	h.Observe(0.5, "/a")
+
+	w = Writer{}
+	h.Collect(&w)
+
+	assert.Equal(t, `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 0
+latency_seconds_bucket{route="/a",le="1"} 1
+latency_seconds_bucket{route="/a",le="+Inf"} 1
+latency_seconds_sum{route="/a"} 0.5
+latency_seconds_count{route="/a"} 1
+latency_seconds_bucket{route="/b",le="0.1"} 2
+latency_seconds_bucket{route="/b",le="1"} 3
+latency_seconds_bucket{route="/b",le="+Inf"} 4
+latency_seconds_sum{route="/b"} 2.65
+latency_seconds_count{route="/b"} 4
+`, w.buf.String())
+
+	assert.Panics(t, func() {
+		h.Observe(1, "/a", "extra")
+	})
+}
+
+func TestHistogramVecDataRace(t *testing.T) {
+	h := NewHistogramVec("latency_seconds", "Latency.", DefaultBuckets, "route", "method")
+
+	var wg sync.WaitGroup
+	wg.Add(100)
+
+	for i := 0; i < 100; i++ {
+		go func() {
+			defer wg.Done()
+
+			h.Observe(0.01, "/get", "GET")
+
+			var w Writer
+			h.Collect(&w)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.EqualValues(t, 100, h.histograms["/get\xffGET"].count)
+}
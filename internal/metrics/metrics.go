@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Type is the type of a metric family.
+type Type string
+
+// Metric types.
+const (
+	Counter   Type = "counter"
+	Gauge     Type = "gauge"
+	Histogram Type = "histogram"
+)
+
+type (
+	// Collector writes its metric families when the registry is scraped.
+	Collector interface {
+		Collect(w *Writer)
+	}
+
+	// Registry is a set of collectors which are exposed in the Prometheus text exposition format.
+	Registry struct {
+		m          sync.Mutex
+		collectors []Collector
+	}
+
+	// Writer writes metric families in the Prometheus text exposition format.
+	Writer struct {
+		buf bytes.Buffer
+	}
+
+	// Label is a metric label's name and value.
+	Label struct {
+		Name  string
+		Value string
+	}
+)
+
+// NewRegistry returns a new registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds collectors to the registry.
+func (r *Registry) Register(collectors ...Collector) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.collectors = append(r.collectors, collectors...)
+}
+
+// Gather returns the metrics of all collectors in the text exposition format.
+func (r *Registry) Gather() []byte {
+	r.m.Lock()
+	collectors := make([]Collector, len(r.collectors))
+	copy(collectors, r.collectors)
+	r.m.Unlock()
+
+	var w Writer
+	for _, c := range collectors {
+		c.Collect(&w)
+	}
+
+	return w.buf.Bytes()
+}
+
+// ServeHTTP implements http.Handler interface.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(r.Gather())
+}
+
+// Family writes the HELP and TYPE lines of a metric family.
+func (w *Writer) Family(name, help string, typ Type) {
+	w.buf.WriteString("# HELP ")
+	w.buf.WriteString(name)
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(escapeHelp(help))
+	w.buf.WriteString("\n# TYPE ")
+	w.buf.WriteString(name)
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(string(typ))
+	w.buf.WriteByte('\n')
+}
+
+// Sample writes a single sample line.
+func (w *Writer) Sample(name string, value float64, labels ...Label) {
+	w.buf.WriteString(name)
+
+	if len(labels) > 0 {
+		w.buf.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			w.buf.WriteString(l.Name)
+			w.buf.WriteString(`="`)
+			w.buf.WriteString(escapeLabelValue(l.Value))
+			w.buf.WriteByte('"')
+		}
+		w.buf.WriteByte('}')
+	}
+
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(formatFloat(value))
+	w.buf.WriteByte('\n')
+}
+
+// formatFloat formats the value as Prometheus expects it.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// escapeHelp escapes backslashes and line feeds of a help text.
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+// escapeLabelValue escapes backslashes, double quotes and line feeds of a label value.
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type collectorFunc func(w *Writer)
+
+func (f collectorFunc) Collect(w *Writer) {
+	f(w)
+}
+
+func TestWriter(t *testing.T) {
+	var w Writer
+
+	w.Family("requests_total", "Total number of requests.\nWith a \\ backslash.", Counter)
+	w.Sample("requests_total", 10)
+	w.Sample("requests_total", 1.5, Label{Name: "path", Value: `/a"b\c` + "\n"}, Label{Name: "code", Value: "200"})
+
+	assert.Equal(t, `# HELP requests_total Total number of requests.\nWith a \\ backslash.
+# TYPE requests_total counter
+requests_total 10
+requests_total{path="/a\"b\\c\n",code="200"} 1.5
+`, w.buf.String())
+}
+
+func TestFormatFloat(t *testing.T) {
+	assert.Equal(t, "+Inf", formatFloat(math.Inf(1)))
+	assert.Equal(t, "-Inf", formatFloat(math.Inf(-1)))
+	assert.Equal(t, "NaN", formatFloat(math.NaN()))
+	assert.Equal(t, "0.005", formatFloat(0.005))
+	assert.Equal(t, "1e+06", formatFloat(1000000))
+	assert.Equal(t, "42", formatFloat(42))
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+
+	assert.Empty(t, r.Gather())
+
+	r.Register(
+		collectorFunc(func(w *Writer) {
+			w.Family("size", "Size.", Gauge)
+			w.Sample("size", 3)
+		}),
+		collectorFunc(func(w *Writer) {
+			w.Family("hits_total", "Hits.", Counter)
+			w.Sample("hits_total", 7)
+		}),
+	)
+
+	expected := "# HELP size Size.\n# TYPE size gauge\nsize 3\n# HELP hits_total Hits.\n# TYPE hits_total counter\nhits_total 7\n"
+	assert.Equal(t, expected, string(r.Gather()))
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	assert.NoError(t, err)
+
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, ContentType, rr.Header().Get("Content-Type"))
+	assert.Equal(t, expected, rr.Body.String())
+}
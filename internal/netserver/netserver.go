@@ -0,0 +1,147 @@
+// Package netserver accepts and tracks the connections of the cache's TCP servers,
+// the protocol of each connection is spoken by the server's handler.
+package netserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+)
+
+// ErrServerClosed is returned by Serve once the server is closed.
+var ErrServerClosed = errors.New("netserver: Server closed")
+
+type (
+	// Handler serves the connection until it's closed, ctx is cancelled once the server is closed.
+	// The id numbers the server's connections from 1, the connection is closed once the handler returns.
+	Handler func(ctx context.Context, conn net.Conn, id int64)
+
+	// Server serves the connections of its listeners with the handler.
+	Server struct {
+		handler Handler
+
+		// ctx is the context of the handlers, it's cancelled once the server is closed.
+		ctx    context.Context
+		cancel context.CancelFunc
+
+		m         sync.Mutex
+		listeners map[net.Listener]struct{}
+		conns     map[net.Conn]struct{}
+		closed    bool
+		total     int64
+
+		wg sync.WaitGroup
+	}
+)
+
+// New returns a new server which serves its connections with the handler.
+func New(handler Handler) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Server{
+		handler:   handler,
+		ctx:       ctx,
+		cancel:    cancel,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// ListenAndServe listens on the TCP address and serves the clients which connect to it.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(l)
+}
+
+// Serve serves the clients which connect to the listener until the server is closed, the listener is closed by Close.
+func (s *Server) Serve(l net.Listener) error {
+	s.m.Lock()
+	if s.closed {
+		s.m.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.m.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.m.Lock()
+			closed := s.closed
+			s.m.Unlock()
+
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		s.m.Lock()
+		if s.closed {
+			s.m.Unlock()
+			conn.Close()
+			return ErrServerClosed
+		}
+		s.conns[conn] = struct{}{}
+		s.total++
+		id := s.total
+		s.wg.Add(1)
+		s.m.Unlock()
+
+		go s.serveConn(conn, id)
+	}
+}
+
+// Close closes the listeners and the connections, and waits for the handlers to return.
+func (s *Server) Close() error {
+	s.m.Lock()
+	s.closed = true
+	s.cancel()
+
+	for l := range s.listeners {
+		l.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.m.Unlock()
+
+	s.wg.Wait()
+	return nil
+}
+
+// Connections returns the number of open connections.
+func (s *Server) Connections() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return len(s.conns)
+}
+
+// TotalConnections returns the number of connections which were ever opened.
+func (s *Server) TotalConnections() int64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.total
+}
+
+// serveConn runs the handler of the connection and forgets the connection once it returns.
+func (s *Server) serveConn(conn net.Conn, id int64) {
+	defer s.wg.Done()
+	defer func() {
+		s.m.Lock()
+		delete(s.conns, conn)
+		s.m.Unlock()
+
+		conn.Close()
+	}()
+
+	s.handler(s.ctx, conn, id)
+}
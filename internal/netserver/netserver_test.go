@@ -0,0 +1,46 @@
+package netserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	ids := make(chan int64)
+	srv := New(func(ctx context.Context, conn net.Conn, id int64) {
+		ids <- id
+		// The connection is served until the server is closed.
+		<-ctx.Done()
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	done := make(chan error)
+	go func() { done <- srv.Serve(l) }()
+
+	for i := int64(1); i <= 2; i++ {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		assert.NoError(t, err)
+		defer conn.Close()
+
+		assert.Equal(t, i, <-ids)
+	}
+	assert.Equal(t, 2, srv.Connections())
+	assert.Equal(t, int64(2), srv.TotalConnections())
+
+	// Close waits for the handlers, and closes the connections once they return.
+	assert.NoError(t, srv.Close())
+	assert.ErrorIs(t, <-done, ErrServerClosed)
+	assert.Zero(t, srv.Connections())
+
+	_, err = net.DialTimeout("tcp", l.Addr().String(), time.Second)
+	assert.Error(t, err)
+
+	l, err = net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, srv.Serve(l), ErrServerClosed)
+}
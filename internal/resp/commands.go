@@ -0,0 +1,420 @@
+package resp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
+	"github.com/MojtabaArezoomand/lru_cache/lru"
+)
+
+// version is the Redis version reported to clients, which pick the commands they send by it.
+const version = "7.0.0"
+
+// command is a command which the server handles.
+type command struct {
+	// arity is the exact number of arguments including the command's name, or the negated minimum number of them.
+	arity  int
+	handle func(s *session, args [][]byte)
+}
+
+// commands are the supported commands by their lowercase names.
+var commands = map[string]command{
+	"get":      {arity: 2, handle: get},
+	"set":      {arity: -3, handle: set},
+	"del":      {arity: -2, handle: del},
+	"exists":   {arity: -2, handle: exists},
+	"flushall": {arity: -1, handle: flushAll},
+	"ttl":      {arity: 2, handle: ttl},
+	"expire":   {arity: 3, handle: expire},
+	"mget":     {arity: -2, handle: mget},
+	"mset":     {arity: -3, handle: mset},
+	"ping":     {arity: -1, handle: ping},
+	"info":     {arity: -1, handle: info},
+	"hello":    {arity: -1, handle: hello},
+	"command":  {arity: -1, handle: commandDocs},
+	"quit":     {arity: -1, handle: quit},
+}
+
+// Error replies.
+const (
+	errSyntax     = "ERR syntax error"
+	errNotInteger = "ERR value is not an integer or out of range"
+)
+
+// exec runs the command and writes its reply.
+func (s *session) exec(args [][]byte) {
+	name := strings.ToLower(string(args[0]))
+
+	cmd, ok := commands[name]
+	if !ok {
+		var b strings.Builder
+		for _, arg := range args[1:] {
+			fmt.Fprintf(&b, "'%s' ", arg)
+		}
+		s.w.error(fmt.Sprintf("ERR unknown command '%s', with args beginning with: %s", args[0], b.String()))
+		return
+	}
+
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		s.w.error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+		return
+	}
+
+	cmd.handle(s, args)
+}
+
+// cacheError writes the reply of an unexpected cache error.
+func (s *session) cacheError(err error) {
+	s.w.error("ERR " + err.Error())
+}
+
+// encode returns the value as it's sent to clients.
+// Strings are sent as they are, other values as JSON, like the HTTP API returns them.
+func encode(val any) []byte {
+	if s, ok := val.(string); ok {
+		return []byte(s)
+	}
+
+	b, err := json.Marshal(val)
+	if err != nil {
+		return []byte(fmt.Sprint(val))
+	}
+	return b
+}
+
+// parseTTL parses a positive number of units as a ttl.
+func parseTTL(arg []byte, unit time.Duration) (time.Duration, bool) {
+	n, err := strconv.ParseInt(string(arg), 10, 64)
+	if err != nil || n <= 0 || n > math.MaxInt64/int64(unit) {
+		return 0, false
+	}
+
+	return time.Duration(n) * unit, true
+}
+
+// get handles GET key.
+func get(s *session, args [][]byte) {
+	val, err := s.cache.Get(s.ctx, string(args[1]))
+	if errors.Is(err, cache.ErrNotFound) {
+		s.w.null()
+	} else if err != nil {
+		s.cacheError(err)
+	} else {
+		s.w.bulk(encode(val))
+	}
+}
+
+// set handles SET key value [EX seconds | PX milliseconds] [NX | XX].
+func set(s *session, args [][]byte) {
+	var ttl time.Duration
+	cond := lru.SetAlways
+
+	for i := 3; i < len(args); i++ {
+		switch opt := strings.ToLower(string(args[i])); {
+		case (opt == "ex" || opt == "px") && ttl == 0 && i+1 < len(args):
+			unit := time.Second
+			if opt == "px" {
+				unit = time.Millisecond
+			}
+
+			i++
+			var ok bool
+			if ttl, ok = parseTTL(args[i], unit); !ok {
+				if _, err := strconv.ParseInt(string(args[i]), 10, 64); err != nil {
+					s.w.error(errNotInteger)
+				} else {
+					s.w.error("ERR invalid expire time in 'set' command")
+				}
+				return
+			}
+		case opt == "nx" && cond == lru.SetAlways:
+			cond = lru.SetIfAbsent
+		case opt == "xx" && cond == lru.SetAlways:
+			cond = lru.SetIfPresent
+		default:
+			s.w.error(errSyntax)
+			return
+		}
+	}
+
+	key, val := string(args[1]), string(args[2])
+
+	var ok bool
+	var err error
+	if ttl > 0 {
+		ok, err = s.cache.SetWithTTLIf(s.ctx, key, val, ttl, cond)
+	} else {
+		ok, err = s.cache.SetIf(s.ctx, key, val, cond)
+	}
+
+	if err != nil {
+		s.cacheError(err)
+	} else if !ok {
+		s.w.null()
+	} else {
+		s.w.simple("OK")
+	}
+}
+
+// del handles DEL key [key ...].
+func del(s *session, args [][]byte) {
+	var n int64
+	for _, key := range args[1:] {
+		err := s.cache.Delete(s.ctx, string(key))
+		if err == nil {
+			n++
+		} else if !errors.Is(err, cache.ErrNotFound) {
+			s.cacheError(err)
+			return
+		}
+	}
+
+	s.w.integer(n)
+}
+
+// exists handles EXISTS key [key ...], a key which is given more than once is counted more than once.
+// Keys are peeked, so they don't count as hits or misses and aren't loaded from the store.
+func exists(s *session, args [][]byte) {
+	var n int64
+	for _, key := range args[1:] {
+		_, err := s.cache.PeekItem(s.ctx, string(key))
+		if err == nil {
+			n++
+		} else if !errors.Is(err, cache.ErrNotFound) {
+			s.cacheError(err)
+			return
+		}
+	}
+
+	s.w.integer(n)
+}
+
+// flushAll handles FLUSHALL [ASYNC | SYNC], the cache is always flushed synchronously.
+func flushAll(s *session, args [][]byte) {
+	if len(args) > 2 {
+		s.w.error(errSyntax)
+		return
+	}
+	if len(args) == 2 {
+		if mode := strings.ToLower(string(args[1])); mode != "async" && mode != "sync" {
+			s.w.error(errSyntax)
+			return
+		}
+	}
+
+	if err := s.cache.Flush(s.ctx); err != nil {
+		s.cacheError(err)
+		return
+	}
+
+	s.w.simple("OK")
+}
+
+// ttl handles TTL key, it's -2 if the key doesn't exist and -1 if it never expires.
+// The key is peeked like by EXISTS.
+func ttl(s *session, args [][]byte) {
+	item, err := s.cache.PeekItem(s.ctx, string(args[1]))
+	if errors.Is(err, cache.ErrNotFound) {
+		s.w.integer(-2)
+	} else if err != nil {
+		s.cacheError(err)
+	} else if item.ExpiresAt.IsZero() {
+		s.w.integer(-1)
+	} else {
+		s.w.integer((item.ExpiresAt.Sub(s.cache.Now()).Milliseconds() + 500) / 1000)
+	}
+}
+
+// expire handles EXPIRE key seconds, a non-positive ttl deletes the key.
+func expire(s *session, args [][]byte) {
+	key := string(args[1])
+
+	seconds, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		s.w.error(errNotInteger)
+		return
+	}
+
+	if seconds <= 0 {
+		del(s, args[:2])
+		return
+	}
+
+	ttl, ok := parseTTL(args[2], time.Second)
+	if !ok {
+		s.w.error("ERR invalid expire time in 'expire' command")
+		return
+	}
+
+	if err := s.cache.Expire(s.ctx, key, ttl); errors.Is(err, cache.ErrNotFound) {
+		s.w.integer(0)
+	} else if err != nil {
+		s.cacheError(err)
+	} else {
+		s.w.integer(1)
+	}
+}
+
+// mget handles MGET key [key ...], keys which don't exist are null.
+func mget(s *session, args [][]byte) {
+	vals := make([][]byte, len(args)-1)
+	for i, key := range args[1:] {
+		val, err := s.cache.Get(s.ctx, string(key))
+		if err == nil {
+			vals[i] = encode(val)
+		} else if !errors.Is(err, cache.ErrNotFound) {
+			s.cacheError(err)
+			return
+		}
+	}
+
+	s.w.array(len(vals))
+	for _, val := range vals {
+		if val == nil {
+			s.w.null()
+		} else {
+			s.w.bulk(val)
+		}
+	}
+}
+
+// mset handles MSET key value [key value ...].
+// Unlike Redis, the keys are set one at a time, so other clients may see some of them before the others,
+// and the keys before a failed one stay set.
+func mset(s *session, args [][]byte) {
+	if len(args)%2 == 0 {
+		s.w.error("ERR wrong number of arguments for 'mset' command")
+		return
+	}
+
+	for i := 1; i < len(args); i += 2 {
+		if err := s.cache.Set(s.ctx, string(args[i]), string(args[i+1])); err != nil {
+			s.cacheError(err)
+			return
+		}
+	}
+
+	s.w.simple("OK")
+}
+
+// ping handles PING [message].
+func ping(s *session, args [][]byte) {
+	switch len(args) {
+	case 1:
+		s.w.simple("PONG")
+	case 2:
+		s.w.bulk(args[1])
+	default:
+		s.w.error("ERR wrong number of arguments for 'ping' command")
+	}
+}
+
+// info handles INFO [section ...].
+func info(s *session, args [][]byte) {
+	stats := s.cache.Stats()
+
+	sections := []struct {
+		name  string
+		lines []string
+	}{
+		{name: "Server", lines: []string{
+			"redis_version:" + version,
+			"redis_mode:standalone",
+		}},
+		{name: "Stats", lines: []string{
+			fmt.Sprintf("keyspace_hits:%d", stats.Hits),
+			fmt.Sprintf("keyspace_misses:%d", stats.Misses),
+			fmt.Sprintf("evicted_keys:%d", stats.Evictions),
+			fmt.Sprintf("expired_keys:%d", stats.Expirations),
+			fmt.Sprintf("total_sets:%d", stats.Sets),
+			fmt.Sprintf("total_deletes:%d", stats.Deletes),
+		}},
+		{name: "Memory", lines: []string{
+			fmt.Sprintf("used_bytes:%d", stats.Bytes),
+		}},
+		{name: "Keyspace", lines: []string{
+			fmt.Sprintf("db0:keys=%d", stats.Size),
+		}},
+	}
+
+	wanted := make(map[string]bool)
+	for _, arg := range args[1:] {
+		wanted[strings.ToLower(string(arg))] = true
+	}
+	all := len(wanted) == 0 || wanted["all"] || wanted["default"] || wanted["everything"]
+
+	var b strings.Builder
+	for _, section := range sections {
+		if !all && !wanted[strings.ToLower(section.name)] {
+			continue
+		}
+
+		if b.Len() > 0 {
+			b.WriteString("\r\n")
+		}
+		fmt.Fprintf(&b, "# %s\r\n", section.name)
+		for _, line := range section.lines {
+			b.WriteString(line)
+			b.WriteString("\r\n")
+		}
+	}
+
+	s.w.verbatim(b.String())
+}
+
+// hello handles HELLO [protover [SETNAME clientname]], which switches the protocol version.
+func hello(s *session, args [][]byte) {
+	if len(args) > 1 {
+		proto, err := strconv.Atoi(string(args[1]))
+		if err != nil {
+			s.w.error("ERR Protocol version is not an integer or out of range")
+			return
+		}
+		if proto != 2 && proto != 3 {
+			s.w.error("NOPROTO unsupported protocol version")
+			return
+		}
+
+		// The client's name is accepted but not kept.
+		if len(args) > 2 && (len(args) != 4 || strings.ToLower(string(args[2])) != "setname") {
+			s.w.error(errSyntax)
+			return
+		}
+
+		s.w.proto = proto
+	}
+
+	s.w.mapHeader(7)
+	s.w.bulk([]byte("server"))
+	s.w.bulk([]byte("redis"))
+	s.w.bulk([]byte("version"))
+	s.w.bulk([]byte(version))
+	s.w.bulk([]byte("proto"))
+	s.w.integer(int64(s.w.proto))
+	s.w.bulk([]byte("id"))
+	s.w.integer(s.id)
+	s.w.bulk([]byte("mode"))
+	s.w.bulk([]byte("standalone"))
+	s.w.bulk([]byte("role"))
+	s.w.bulk([]byte("master"))
+	s.w.bulk([]byte("modules"))
+	s.w.array(0)
+}
+
+// commandDocs handles COMMAND and its subcommands, which clients like redis-cli call on connect.
+// There are no command docs, so the reply is always empty.
+func commandDocs(s *session, args [][]byte) {
+	s.w.array(0)
+}
+
+// quit handles QUIT, the connection is closed once the reply is sent.
+func quit(s *session, args [][]byte) {
+	s.w.simple("OK")
+	s.quit = true
+}
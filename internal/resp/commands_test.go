@@ -0,0 +1,248 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
+	"github.com/MojtabaArezoomand/lru_cache/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// testSession is a session whose replies are written to a buffer.
+type testSession struct {
+	*session
+	out *bytes.Buffer
+}
+
+// newTestSession returns a new session of a new cache which is closed when the test finishes.
+func newTestSession(t *testing.T, opts ...cache.Option) testSession {
+	c := testutil.NewCache(t, opts...)
+
+	var out bytes.Buffer
+	return testSession{
+		session: &session{id: 1, cache: c, ctx: context.Background(), w: &writer{Writer: bufio.NewWriter(&out), proto: 2}},
+		out:     &out,
+	}
+}
+
+// run runs the command and returns its reply.
+func (s testSession) run(args ...string) string {
+	cmd := make([][]byte, len(args))
+	for i, arg := range args {
+		cmd[i] = []byte(arg)
+	}
+
+	s.exec(cmd)
+	s.w.Flush()
+
+	reply := s.out.String()
+	s.out.Reset()
+	return reply
+}
+
+func TestGetSet(t *testing.T) {
+	s := newTestSession(t)
+
+	assert.Equal(t, "$-1\r\n", s.run("GET", "key"))
+	assert.Equal(t, "+OK\r\n", s.run("SET", "key", "val"))
+	assert.Equal(t, "$3\r\nval\r\n", s.run("get", "key"))
+
+	// Values set over HTTP are returned as JSON.
+	assert.NoError(t, s.cache.Set(context.Background(), "json", []any{1.0, "val"}))
+	assert.Equal(t, "$9\r\n[1,\"val\"]\r\n", s.run("GET", "json"))
+
+	assert.Equal(t, "$-1\r\n", s.run("SET", "key", "other", "NX"))
+	assert.Equal(t, "+OK\r\n", s.run("SET", "key", "other", "XX"))
+	assert.Equal(t, "$-1\r\n", s.run("SET", "missing", "val", "XX"))
+	assert.Equal(t, "+OK\r\n", s.run("SET", "new", "val", "nx", "ex", "10"))
+	assert.Equal(t, ":10\r\n", s.run("TTL", "new"))
+	assert.Equal(t, "+OK\r\n", s.run("SET", "new", "val", "PX", "2500"))
+	assert.Equal(t, ":2\r\n", s.run("TTL", "new"))
+
+	// SET without a ttl clears the key's ttl.
+	assert.Equal(t, "+OK\r\n", s.run("SET", "new", "val"))
+	assert.Equal(t, ":-1\r\n", s.run("TTL", "new"))
+
+	testCases := []struct {
+		args  []string
+		reply string
+	}{
+		{args: []string{"SET", "key"}, reply: "-ERR wrong number of arguments for 'set' command\r\n"},
+		{args: []string{"SET", "key", "val", "NX", "XX"}, reply: "-ERR syntax error\r\n"},
+		{args: []string{"SET", "key", "val", "EX", "1", "PX", "1"}, reply: "-ERR syntax error\r\n"},
+		{args: []string{"SET", "key", "val", "EX"}, reply: "-ERR syntax error\r\n"},
+		{args: []string{"SET", "key", "val", "KEEPTTL"}, reply: "-ERR syntax error\r\n"},
+		{args: []string{"SET", "key", "val", "EX", "ten"}, reply: "-ERR value is not an integer or out of range\r\n"},
+		{args: []string{"SET", "key", "val", "EX", "0"}, reply: "-ERR invalid expire time in 'set' command\r\n"},
+		{args: []string{"SET", "key", "val", "EX", "9223372036854775807"}, reply: "-ERR invalid expire time in 'set' command\r\n"},
+		{args: []string{"GET"}, reply: "-ERR wrong number of arguments for 'get' command\r\n"},
+		{args: []string{"GET", "a", "b"}, reply: "-ERR wrong number of arguments for 'get' command\r\n"},
+		{args: []string{"INCR", "key", "1"}, reply: "-ERR unknown command 'INCR', with args beginning with: 'key' '1' \r\n"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.reply, s.run(tc.args...), "%v", tc.args)
+	}
+}
+
+func TestSetTooLarge(t *testing.T) {
+	s := newTestSession(t, cache.WithMaxBytes(8), cache.WithSizer(cache.JSONSizer))
+
+	assert.Equal(t, "-ERR value is larger than the cache's max bytes\r\n", s.run("SET", "key", "too long value"))
+}
+
+func TestDelExists(t *testing.T) {
+	s := newTestSession(t)
+
+	assert.Equal(t, "+OK\r\n", s.run("MSET", "a", "1", "b", "2"))
+	assert.Equal(t, ":3\r\n", s.run("EXISTS", "a", "b", "a", "c"))
+	assert.Equal(t, ":1\r\n", s.run("DEL", "a", "c"))
+	assert.Equal(t, ":0\r\n", s.run("EXISTS", "a"))
+	assert.Equal(t, "+OK\r\n", s.run("FLUSHALL"))
+	assert.Equal(t, ":0\r\n", s.run("EXISTS", "b"))
+	assert.Equal(t, "+OK\r\n", s.run("FLUSHALL", "ASYNC"))
+	assert.Equal(t, "-ERR syntax error\r\n", s.run("FLUSHALL", "NOW"))
+	assert.Equal(t, "-ERR syntax error\r\n", s.run("FLUSHALL", "SYNC", "ASYNC"))
+}
+
+func TestExistsTTLStats(t *testing.T) {
+	s := newTestSession(t)
+
+	// EXISTS and TTL don't count as hits or misses.
+	assert.Equal(t, "+OK\r\n", s.run("SET", "key", "val"))
+	assert.Equal(t, ":1\r\n", s.run("EXISTS", "key", "missing"))
+	assert.Equal(t, ":-1\r\n", s.run("TTL", "key"))
+	assert.Equal(t, ":-2\r\n", s.run("TTL", "missing"))
+
+	stats := s.cache.Stats()
+	assert.Zero(t, stats.Hits)
+	assert.Zero(t, stats.Misses)
+}
+
+func TestMGetMSet(t *testing.T) {
+	s := newTestSession(t)
+
+	assert.Equal(t, "+OK\r\n", s.run("MSET", "a", "1", "b", "2"))
+	assert.Equal(t, "*3\r\n$1\r\n1\r\n$-1\r\n$1\r\n2\r\n", s.run("MGET", "a", "c", "b"))
+	assert.Equal(t, "-ERR wrong number of arguments for 'mset' command\r\n", s.run("MSET", "a", "1", "b"))
+	assert.Equal(t, "-ERR wrong number of arguments for 'mget' command\r\n", s.run("MGET"))
+}
+
+func TestExpire(t *testing.T) {
+	s := newTestSession(t)
+
+	assert.Equal(t, ":-2\r\n", s.run("TTL", "key"))
+	assert.Equal(t, ":0\r\n", s.run("EXPIRE", "key", "10"))
+
+	assert.Equal(t, "+OK\r\n", s.run("SET", "key", "val"))
+	assert.Equal(t, ":-1\r\n", s.run("TTL", "key"))
+	assert.Equal(t, ":1\r\n", s.run("EXPIRE", "key", "10"))
+	assert.Equal(t, ":10\r\n", s.run("TTL", "key"))
+	assert.Equal(t, "$3\r\nval\r\n", s.run("GET", "key"))
+
+	assert.Equal(t, "-ERR value is not an integer or out of range\r\n", s.run("EXPIRE", "key", "soon"))
+	assert.Equal(t, "-ERR invalid expire time in 'expire' command\r\n", s.run("EXPIRE", "key", "9223372036854775807"))
+
+	// A non-positive ttl deletes the key.
+	assert.Equal(t, ":1\r\n", s.run("EXPIRE", "key", "0"))
+	assert.Equal(t, ":-2\r\n", s.run("TTL", "key"))
+
+	assert.Equal(t, "+OK\r\n", s.run("SET", "key", "val", "PX", "1"))
+	time.Sleep(2 * time.Millisecond)
+	assert.Equal(t, ":-2\r\n", s.run("TTL", "key"))
+}
+
+func TestTTLClock(t *testing.T) {
+	now := time.Unix(1000000000, 0)
+	s := newTestSession(t, cache.WithClock(func() time.Time { return now }))
+
+	// The ttl is relative to the cache's clock.
+	assert.Equal(t, "+OK\r\n", s.run("SET", "key", "val", "EX", "100"))
+	assert.Equal(t, ":100\r\n", s.run("TTL", "key"))
+}
+
+func TestPing(t *testing.T) {
+	s := newTestSession(t)
+
+	assert.Equal(t, "+PONG\r\n", s.run("PING"))
+	assert.Equal(t, "$5\r\nhello\r\n", s.run("PING", "hello"))
+	assert.Equal(t, "-ERR wrong number of arguments for 'ping' command\r\n", s.run("PING", "a", "b"))
+}
+
+func TestInfo(t *testing.T) {
+	s := newTestSession(t)
+
+	s.run("SET", "key", "val")
+	s.run("GET", "key")
+	s.run("GET", "missing")
+
+	reply := s.run("INFO")
+	assert.Contains(t, reply, "# Server\r\nredis_version:7.0.0\r\n")
+	assert.Contains(t, reply, "keyspace_hits:1\r\nkeyspace_misses:1\r\n")
+	assert.Contains(t, reply, "# Keyspace\r\ndb0:keys=1\r\n")
+
+	assert.Equal(t, "$24\r\n# Keyspace\r\ndb0:keys=1\r\n\r\n", s.run("INFO", "keyspace"))
+	assert.Equal(t, "$0\r\n\r\n", s.run("INFO", "unknown"))
+
+	s.run("HELLO", "3")
+	assert.Equal(t, "=28\r\ntxt:# Keyspace\r\ndb0:keys=1\r\n\r\n", s.run("INFO", "keyspace"))
+}
+
+func TestHello(t *testing.T) {
+	s := newTestSession(t)
+
+	hello2 := "*14\r\n$6\r\nserver\r\n$5\r\nredis\r\n$7\r\nversion\r\n$5\r\n7.0.0\r\n$5\r\nproto\r\n:2\r\n$2\r\nid\r\n:1\r\n" +
+		"$4\r\nmode\r\n$10\r\nstandalone\r\n$4\r\nrole\r\n$6\r\nmaster\r\n$7\r\nmodules\r\n*0\r\n"
+	assert.Equal(t, hello2, s.run("HELLO"))
+
+	assert.Equal(t, "-NOPROTO unsupported protocol version\r\n", s.run("HELLO", "4"))
+	assert.Equal(t, "-ERR Protocol version is not an integer or out of range\r\n", s.run("HELLO", "three"))
+	assert.Equal(t, "-ERR syntax error\r\n", s.run("HELLO", "3", "AUTH", "user", "pass"))
+
+	reply := s.run("HELLO", "3", "SETNAME", "client")
+	assert.Contains(t, reply, "%7\r\n")
+	assert.Contains(t, reply, "$5\r\nproto\r\n:3\r\n")
+
+	// RESP3 has a null of its own.
+	assert.Equal(t, "_\r\n", s.run("GET", "key"))
+	assert.Equal(t, "*1\r\n_\r\n", s.run("MGET", "key"))
+
+	s.run("HELLO", "2")
+	assert.Equal(t, "$-1\r\n", s.run("GET", "key"))
+}
+
+func TestCommandQuit(t *testing.T) {
+	s := newTestSession(t)
+
+	assert.Equal(t, "*0\r\n", s.run("COMMAND", "DOCS"))
+	assert.False(t, s.quit)
+
+	assert.Equal(t, "+OK\r\n", s.run("QUIT"))
+	assert.True(t, s.quit)
+}
+
+func TestCacheError(t *testing.T) {
+	s := newTestSession(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.ctx = ctx
+
+	for _, args := range [][]string{
+		{"GET", "key"},
+		{"SET", "key", "val"},
+		{"DEL", "key"},
+		{"EXISTS", "key"},
+		{"FLUSHALL"},
+		{"TTL", "key"},
+		{"EXPIRE", "key", "1"},
+		{"MGET", "key"},
+		{"MSET", "key", "val"},
+	} {
+		assert.Equal(t, "-ERR context canceled\r\n", s.run(args...), "%v", args)
+	}
+}
@@ -0,0 +1,225 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Limits of a command, they match Redis' defaults.
+const (
+	maxArgs    = 1024 * 1024
+	maxBulkLen = 512 * 1024 * 1024
+	maxInline  = 64 * 1024
+)
+
+// Initial capacities of a command's buffers, which grow as the data arrives,
+// so the lengths sent by a client don't allocate more than the data it sends.
+const (
+	initialArgs    = 64
+	initialBulkLen = 64 * 1024
+)
+
+// errProtocol is returned when the client sends something which isn't a command.
+var errProtocol = errors.New("Protocol error")
+
+// readCommand reads a command, which is either an array of bulk strings or an inline command.
+// It returns a nil command for empty inline commands, which are ignored.
+func readCommand(r *bufio.Reader) ([][]byte, error) {
+	b, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if b[0] != '*' {
+		return readInline(r)
+	}
+
+	n, err := readLength(r, '*', maxArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([][]byte, 0, minInt(n, initialArgs))
+	for i := 0; i < n; i++ {
+		size, err := readLength(r, '$', maxBulkLen)
+		if err != nil {
+			return nil, err
+		}
+
+		arg, err := readBulk(r, size)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, arg)
+	}
+
+	return args, nil
+}
+
+// readBulk reads the data of a bulk string of the given size followed by "\r\n".
+func readBulk(r *bufio.Reader, size int) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(minInt(size+2, initialBulkLen))
+
+	if _, err := io.CopyN(&buf, r, int64(size+2)); errors.Is(err, io.EOF) {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+
+	arg := buf.Bytes()
+	if !bytes.HasSuffix(arg, []byte("\r\n")) {
+		return nil, fmt.Errorf("%w: expected '\\r\\n' after bulk string", errProtocol)
+	}
+
+	return arg[:size], nil
+}
+
+// minInt returns the smaller of a and b.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// readInline reads a command which is sent as a line of space separated arguments, like telnet does.
+func readInline(r *bufio.Reader) ([][]byte, error) {
+	line, err := readLine(r, maxInline)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.Fields(line), nil
+}
+
+// readLength reads a line which is the prefix followed by a length between 0 and max.
+// A negative length of an array means an empty command.
+func readLength(r *bufio.Reader, prefix byte, max int) (int, error) {
+	line, err := readLine(r, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(line) == 0 || line[0] != prefix {
+		return 0, fmt.Errorf("%w: expected '%c', got '%s'", errProtocol, prefix, line)
+	}
+
+	n, err := strconv.Atoi(string(line[1:]))
+	if err != nil || n > max || (n < 0 && prefix != '*') {
+		return 0, fmt.Errorf("%w: invalid length '%s'", errProtocol, line)
+	}
+	if n < 0 {
+		return 0, nil
+	}
+
+	return n, nil
+}
+
+// readLine reads a line ending in "\r\n" or "\n" which is at most max bytes long, without its ending.
+func readLine(r *bufio.Reader, max int) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > max+2 {
+			return nil, fmt.Errorf("%w: too big line", errProtocol)
+		}
+
+		if err == nil {
+			break
+		} else if !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+	}
+
+	line = line[:len(line)-1]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+
+	return line, nil
+}
+
+// writer writes the replies of a connection in the protocol version chosen by the client.
+type writer struct {
+	*bufio.Writer
+	// proto is the protocol version, 2 or 3.
+	proto int
+}
+
+// simple writes a simple string.
+func (w *writer) simple(s string) {
+	w.WriteByte('+')
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+// error writes an error, whose message starts with its code like "ERR".
+func (w *writer) error(msg string) {
+	w.WriteByte('-')
+	w.WriteString(msg)
+	w.WriteString("\r\n")
+}
+
+// integer writes an integer.
+func (w *writer) integer(n int64) {
+	w.WriteByte(':')
+	w.WriteString(strconv.FormatInt(n, 10))
+	w.WriteString("\r\n")
+}
+
+// bulk writes a bulk string.
+func (w *writer) bulk(b []byte) {
+	w.WriteByte('$')
+	w.WriteString(strconv.Itoa(len(b)))
+	w.WriteString("\r\n")
+	w.Write(b)
+	w.WriteString("\r\n")
+}
+
+// null writes a null, which is a null bulk string in RESP2.
+func (w *writer) null() {
+	if w.proto == 3 {
+		w.WriteString("_\r\n")
+		return
+	}
+	w.WriteString("$-1\r\n")
+}
+
+// array writes the header of an array of n elements, the elements are written next.
+func (w *writer) array(n int) {
+	w.WriteByte('*')
+	w.WriteString(strconv.Itoa(n))
+	w.WriteString("\r\n")
+}
+
+// mapHeader writes the header of a map of n key-values, which is a flat array in RESP2.
+func (w *writer) mapHeader(n int) {
+	if w.proto == 3 {
+		w.WriteByte('%')
+		w.WriteString(strconv.Itoa(n))
+		w.WriteString("\r\n")
+		return
+	}
+	w.array(2 * n)
+}
+
+// verbatim writes a text which is meant to be shown as is, which is a bulk string in RESP2.
+func (w *writer) verbatim(text string) {
+	if w.proto != 3 {
+		w.bulk([]byte(text))
+		return
+	}
+
+	w.WriteByte('=')
+	w.WriteString(strconv.Itoa(len(text) + 4))
+	w.WriteString("\r\ntxt:")
+	w.WriteString(text)
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,92 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCommand(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		args  []string
+		err   string
+	}{
+		{name: "array", input: "*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$5\r\nva\r\nl\r\n", args: []string{"SET", "key", "va\r\nl"}},
+		{name: "empty_bulk", input: "*2\r\n$4\r\nECHO\r\n$0\r\n\r\n", args: []string{"ECHO", ""}},
+		{name: "empty_array", input: "*0\r\n", args: []string{}},
+		{name: "null_array", input: "*-1\r\n", args: []string{}},
+		{name: "inline", input: "SET  key\tval\r\n", args: []string{"SET", "key", "val"}},
+		{name: "inline_newline", input: "PING\n", args: []string{"PING"}},
+		{name: "empty_inline", input: "\r\n", args: []string{}},
+		{name: "invalid_array_length", input: "*x\r\n", err: "Protocol error: invalid length '*x'"},
+		{name: "too_many_args", input: "*1048577\r\n", err: "Protocol error: invalid length '*1048577'"},
+		{name: "not_bulk", input: "*1\r\n:1\r\n", err: "Protocol error: expected '$', got ':1'"},
+		{name: "negative_bulk", input: "*1\r\n$-1\r\n", err: "Protocol error: invalid length '$-1'"},
+		{name: "invalid_bulk_ending", input: "*1\r\n$3\r\nGETxx", err: "Protocol error: expected '\\r\\n' after bulk string"},
+		{name: "truncated", input: "*1\r\n$3\r\nGE", err: io.ErrUnexpectedEOF.Error()},
+		{name: "eof", input: "", err: io.EOF.Error()},
+		{name: "too_long_inline", input: strings.Repeat("a", maxInline+1) + "\r\n", err: "Protocol error: too big line"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			args, err := readCommand(bufio.NewReader(strings.NewReader(tc.input)))
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, args, len(tc.args))
+			for i, arg := range tc.args {
+				assert.Equal(t, arg, string(args[i]))
+			}
+		})
+	}
+}
+
+func TestReadCommandAllocations(t *testing.T) {
+	// The announced lengths aren't allocated up front, only as much as the data which arrives.
+	input := fmt.Sprintf("*%d\r\n$%d\r\nGET", maxArgs, maxBulkLen)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := readCommand(bufio.NewReader(strings.NewReader(input)))
+	runtime.ReadMemStats(&after)
+
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1024*1024))
+}
+
+func TestWriter(t *testing.T) {
+	write := func(w *writer) {
+		w.simple("OK")
+		w.error("ERR failed")
+		w.integer(-2)
+		w.bulk([]byte("val"))
+		w.null()
+		w.array(1)
+		w.mapHeader(1)
+		w.verbatim("text")
+	}
+
+	for proto, want := range map[int]string{
+		2: "+OK\r\n-ERR failed\r\n:-2\r\n$3\r\nval\r\n$-1\r\n*1\r\n*2\r\n$4\r\ntext\r\n",
+		3: "+OK\r\n-ERR failed\r\n:-2\r\n$3\r\nval\r\n_\r\n*1\r\n%1\r\n=8\r\ntxt:text\r\n",
+	} {
+		var buf bytes.Buffer
+		w := &writer{Writer: bufio.NewWriter(&buf), proto: proto}
+
+		write(w)
+		assert.NoError(t, w.Flush())
+		assert.Equal(t, want, buf.String(), "RESP%d", proto)
+	}
+}
@@ -0,0 +1,76 @@
+// Package resp serves the cache over the Redis serialization protocol, so Redis clients and tools like redis-cli can use it.
+// Both RESP2 and RESP3 are spoken, clients switch to RESP3 with HELLO 3.
+package resp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log"
+	"net"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
+	"github.com/MojtabaArezoomand/lru_cache/internal/netserver"
+)
+
+// ErrServerClosed is returned by Serve once the server is closed.
+var ErrServerClosed = netserver.ErrServerClosed
+
+type (
+	// Server serves the cache to RESP clients.
+	Server struct {
+		*netserver.Server
+		cache *cache.Cache
+	}
+
+	// session is the state of a client's connection.
+	session struct {
+		id    int64
+		cache *cache.Cache
+		ctx   context.Context
+		w     *writer
+		// quit is set once the connection must be closed after the reply is sent.
+		quit bool
+	}
+)
+
+// NewServer returns a new server of the cache.
+func NewServer(c *cache.Cache) *Server {
+	s := &Server{cache: c}
+	s.Server = netserver.New(s.serveConn)
+	return s
+}
+
+// serveConn runs the commands of the connection until it's closed.
+// Replies are flushed once there is no pipelined command left to read.
+func (s *Server) serveConn(ctx context.Context, conn net.Conn, id int64) {
+	r := bufio.NewReader(conn)
+	sess := session{
+		id:    id,
+		cache: s.cache,
+		ctx:   ctx,
+		w:     &writer{Writer: bufio.NewWriter(conn), proto: 2},
+	}
+
+	for {
+		args, err := readCommand(r)
+		if errors.Is(err, errProtocol) {
+			sess.w.error("ERR " + err.Error())
+			sess.w.Flush()
+			log.Println("error in reading RESP command, reason:", err)
+			return
+		} else if err != nil {
+			return
+		}
+
+		if len(args) > 0 {
+			sess.exec(args)
+		}
+
+		if r.Buffered() == 0 || sess.quit {
+			if err := sess.w.Flush(); err != nil || sess.quit {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package resp
+
+import (
+	"io"
+	"testing"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	addr := testutil.Serve(t, NewServer(testutil.NewCache(t)))
+	conn, r := testutil.Dial(t, addr)
+
+	// Pipelined commands are answered in order.
+	_, err := conn.Write([]byte("*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$3\r\nval\r\n*2\r\n$3\r\nGET\r\n$3\r\nkey\r\nPING\r\n\r\n"))
+	assert.NoError(t, err)
+
+	want := "+OK\r\n$3\r\nval\r\n+PONG\r\n"
+	assert.Equal(t, want, testutil.ReadN(t, r, len(want)))
+
+	// Another client sees the same cache.
+	other, otherR := testutil.Dial(t, addr)
+	_, err = other.Write([]byte("GET key\r\nQUIT\r\n"))
+	assert.NoError(t, err)
+
+	rest, err := io.ReadAll(otherR)
+	assert.NoError(t, err)
+	assert.Equal(t, "$3\r\nval\r\n+OK\r\n", string(rest))
+}
+
+func TestServerProtocolError(t *testing.T) {
+	addr := testutil.Serve(t, NewServer(testutil.NewCache(t)))
+	conn, r := testutil.Dial(t, addr)
+
+	_, err := conn.Write([]byte("*1\r\n:1\r\n"))
+	assert.NoError(t, err)
+
+	rest, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "-ERR Protocol error: expected '$', got ':1'\r\n", string(rest))
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
+	"github.com/MojtabaArezoomand/lru_cache/internal/netserver"
+	"github.com/MojtabaArezoomand/lru_cache/wire"
+)
+
+// maxInFlight is the maximum number of requests of a connection which are handled at once,
+// the connection isn't read while there are as many.
+const maxInFlight = 128
+
+// binaryServer serves the cache over the binary protocol of the wire package.
+type binaryServer struct {
+	*netserver.Server
+	cache *cache.Cache
+}
+
+// newBinaryServer returns a new binary server of the cache.
+func newBinaryServer(c *cache.Cache) *binaryServer {
+	s := &binaryServer{cache: c}
+	s.Server = netserver.New(s.serveConn)
+	return s
+}
+
+// serveConn handles the requests of the connection until it's closed.
+// Each request is handled on its own goroutine and its response is sent once it's ready,
+// responses are flushed once there is no other response waiting to be sent.
+func (s *binaryServer) serveConn(ctx context.Context, conn net.Conn, id int64) {
+	responses := make(chan wire.Response, maxInFlight)
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+
+		w := bufio.NewWriter(conn)
+		var err error
+		// The responses are still received after the connection fails, so the handlers don't block.
+		for resp := range responses {
+			if err != nil {
+				continue
+			}
+
+			err = wire.WriteResponse(w, resp)
+			if err == nil && len(responses) == 0 {
+				err = w.Flush()
+			}
+			if err != nil {
+				conn.Close()
+			}
+		}
+	}()
+
+	var handlers sync.WaitGroup
+	inFlight := make(chan struct{}, maxInFlight)
+	r := bufio.NewReader(conn)
+
+	for {
+		req, err := wire.ReadRequest(r)
+		if errors.Is(err, wire.ErrMalformed) {
+			responses <- wire.Response{ID: req.ID, Status: wire.StatusBadRequest, Body: []byte(err.Error())}
+			continue
+		} else if err != nil {
+			if errors.Is(err, wire.ErrFrameTooLarge) {
+				log.Println("error in reading binary request, reason:", err)
+			}
+			break
+		}
+
+		inFlight <- struct{}{}
+		handlers.Add(1)
+		go func() {
+			defer handlers.Done()
+
+			responses <- s.handle(ctx, req)
+			<-inFlight
+		}()
+	}
+
+	handlers.Wait()
+	close(responses)
+	<-written
+}
+
+// handle runs the request and returns its response.
+func (s *binaryServer) handle(ctx context.Context, req wire.Request) wire.Response {
+	resp := wire.Response{ID: req.ID}
+
+	var err error
+	switch req.Op {
+	case wire.OpPing:
+	case wire.OpGet:
+		var val any
+		if val, err = s.cache.Get(ctx, req.Key); err == nil {
+			resp.Body = encodeValue(val)
+		}
+	case wire.OpSet:
+		if req.Key == "" {
+			return badRequest(resp, "key is required")
+		}
+
+		if req.TTL < 0 {
+			err = s.cache.Set(ctx, req.Key, string(req.Value))
+		} else {
+			err = s.cache.SetWithTTL(ctx, req.Key, string(req.Value), req.TTL)
+		}
+	case wire.OpDelete:
+		err = s.cache.Delete(ctx, req.Key)
+	case wire.OpFlush:
+		err = s.cache.Flush(ctx)
+	case wire.OpStats:
+		resp.Body, err = json.Marshal(s.cache.Stats())
+	default:
+		return badRequest(resp, fmt.Sprintf("unknown op %d", req.Op))
+	}
+
+	switch {
+	case err == nil:
+		resp.Status = wire.StatusOK
+	case errors.Is(err, cache.ErrNotFound):
+		resp.Status = wire.StatusNotFound
+	case errors.Is(err, cache.ErrTooLarge):
+		resp.Status = wire.StatusTooLarge
+	default:
+		resp.Status = wire.StatusError
+		resp.Body = []byte(err.Error())
+		log.Println("error in handling binary request, reason:", err)
+	}
+
+	return resp
+}
+
+// badRequest returns the response with StatusBadRequest and the message.
+func badRequest(resp wire.Response, msg string) wire.Response {
+	resp.Status = wire.StatusBadRequest
+	resp.Body = []byte(msg)
+	return resp
+}
+
+// encodeValue returns the value as it's sent to binary clients.
+// Strings and text values are sent as they are, other values as JSON, like the HTTP API returns them.
+func encodeValue(val any) []byte {
+	switch v := val.(type) {
+	case string:
+		return []byte(v)
+	case encoding.TextMarshaler:
+		if b, err := v.MarshalText(); err == nil {
+			return b
+		}
+	}
+
+	b, err := json.Marshal(val)
+	if err != nil {
+		return []byte(fmt.Sprint(val))
+	}
+	return b
+}
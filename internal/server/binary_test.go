@@ -0,0 +1,256 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
+	"github.com/MojtabaArezoomand/lru_cache/internal/netserver"
+	"github.com/MojtabaArezoomand/lru_cache/internal/testutil"
+	"github.com/MojtabaArezoomand/lru_cache/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBinaryClient returns a client of a new binary server of the cache, which are closed when the test finishes.
+func newTestBinaryClient(t testing.TB, c *cache.Cache) *wire.Client {
+	client, err := wire.Dial(context.Background(), testutil.Serve(t, newBinaryServer(c)))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func TestBinaryServer(t *testing.T) {
+	c := testutil.NewCache(t)
+	client := newTestBinaryClient(t, c)
+	ctx := context.Background()
+
+	assert.NoError(t, client.Ping(ctx))
+
+	_, err := client.Get(ctx, "key")
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+
+	assert.NoError(t, client.Set(ctx, "key", []byte("val")))
+	val, err := client.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "val", string(val))
+
+	// Values are stored as strings, so the HTTP API sees them as strings too.
+	cached, err := c.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "val", cached)
+
+	// Values set over HTTP are returned as JSON.
+	assert.NoError(t, c.Set(ctx, "json", []any{1.0, "val"}))
+	val, err = client.Get(ctx, "json")
+	assert.NoError(t, err)
+	assert.Equal(t, `[1,"val"]`, string(val))
+
+	assert.NoError(t, client.SetWithTTL(ctx, "ttl", []byte("val"), time.Minute))
+	item, err := c.GetItem(ctx, "ttl")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), item.ExpiresAt, time.Second)
+
+	err = client.Set(ctx, "", []byte("val"))
+	assert.Equal(t, &wire.ResponseError{Status: wire.StatusBadRequest, Message: "key is required"}, err)
+
+	stats, err := client.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, c.Stats(), stats)
+
+	assert.NoError(t, client.Delete(ctx, "key"))
+	assert.ErrorIs(t, client.Delete(ctx, "key"), cache.ErrNotFound)
+
+	assert.NoError(t, client.Flush(ctx))
+	_, err = client.Get(ctx, "json")
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+}
+
+func TestBinaryServerErrors(t *testing.T) {
+	c := testutil.NewCache(t, cache.WithMaxBytes(16), cache.WithSizer(cache.JSONSizer))
+	client := newTestBinaryClient(t, c)
+	assert.ErrorIs(t, client.Set(context.Background(), "key", bytes.Repeat([]byte("v"), 32)), cache.ErrTooLarge)
+
+	client = newTestBinaryClient(t, testutil.NewCache(t, cache.WithStore(failingStore{})))
+	_, err := client.Get(context.Background(), "key")
+	assert.Equal(t, &wire.ResponseError{Status: wire.StatusError, Message: "store is down"}, err)
+}
+
+func TestBinaryServerPipelining(t *testing.T) {
+	client := newTestBinaryClient(t, testutil.NewCache(t))
+	ctx := context.Background()
+
+	// Concurrent calls share the client's connection.
+	errs := make(chan error)
+	for i := 0; i < 100; i++ {
+		go func(key string) {
+			if err := client.Set(ctx, key, []byte(key)); err != nil {
+				errs <- err
+				return
+			}
+
+			val, err := client.Get(ctx, key)
+			if err == nil && string(val) != key {
+				err = fmt.Errorf("got %q for %q", val, key)
+			}
+			errs <- err
+		}(fmt.Sprint(i))
+	}
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, <-errs)
+	}
+}
+
+func TestBinaryServerBadRequests(t *testing.T) {
+	conn, r := testutil.Dial(t, testutil.Serve(t, newBinaryServer(testutil.NewCache(t))))
+
+	// A malformed request and an unknown op are answered, and the connection can still be used.
+	_, err := conn.Write([]byte{0, 0, 0, 5, 0, 0, 0, 1, byte(wire.OpGet)})
+	assert.NoError(t, err)
+	assert.NoError(t, wire.WriteRequest(conn, wire.Request{ID: 2, Op: 42}))
+	assert.NoError(t, wire.WriteRequest(conn, wire.Request{ID: 3, Op: wire.OpPing}))
+
+	resps := make(map[uint32]wire.Response)
+	for len(resps) < 3 {
+		resp, err := wire.ReadResponse(r)
+		assert.NoError(t, err)
+		resps[resp.ID] = resp
+	}
+	assert.Equal(t, wire.StatusBadRequest, resps[1].Status)
+	assert.Equal(t, wire.StatusBadRequest, resps[2].Status)
+	assert.Equal(t, "unknown op 42", string(resps[2].Body))
+	assert.Equal(t, wire.StatusOK, resps[3].Status)
+
+	// A frame which is too large closes the connection.
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, wire.MaxFrameSize+1)
+	_, err = conn.Write(length)
+	assert.NoError(t, err)
+
+	_, err = r.ReadByte()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestBinaryServerClose(t *testing.T) {
+	srv := newBinaryServer(testutil.NewCache(t))
+	addr := testutil.Serve(t, srv)
+
+	client, err := wire.Dial(context.Background(), addr)
+	assert.NoError(t, err)
+	defer client.Close()
+	assert.NoError(t, client.Ping(context.Background()))
+
+	// Open connections are closed.
+	assert.NoError(t, srv.Close())
+	assert.Error(t, client.Ping(context.Background()))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, srv.Serve(l), netserver.ErrServerClosed)
+}
+
+// silenceLogs discards the logs of the handlers until the benchmark finishes.
+func silenceLogs(b *testing.B) {
+	log.SetOutput(ioutil.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+// benchmarkHTTP runs the request built by newRequest on many goroutines, over a pool of keep-alive connections.
+func benchmarkHTTP(b *testing.B, c *cache.Cache, newRequest func(url string) (*http.Request, error)) {
+	srv := httptest.NewServer(newRouter(newApp(c)))
+	defer srv.Close()
+
+	client := srv.Client()
+	client.Transport.(*http.Transport).MaxIdleConnsPerHost = 64
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			req, err := newRequest(srv.URL)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				b.Errorf("unexpected status %d", resp.StatusCode)
+				return
+			}
+		}
+	})
+}
+
+// benchmarkBinary runs the call on many goroutines, which pipeline their requests over one connection.
+func benchmarkBinary(b *testing.B, c *cache.Cache, call func(client *wire.Client) error) {
+	client := newTestBinaryClient(b, c)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := call(client); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkGet(b *testing.B) {
+	silenceLogs(b)
+
+	c := testutil.NewCache(b)
+	assert.NoError(b, c.Set(context.Background(), "key", "val"))
+
+	b.Run("http", func(b *testing.B) {
+		benchmarkHTTP(b, c, func(url string) (*http.Request, error) {
+			return http.NewRequest(http.MethodGet, url+"/get/key", nil)
+		})
+	})
+
+	b.Run("binary", func(b *testing.B) {
+		benchmarkBinary(b, c, func(client *wire.Client) error {
+			_, err := client.Get(context.Background(), "key")
+			return err
+		})
+	})
+}
+
+func BenchmarkSet(b *testing.B) {
+	silenceLogs(b)
+
+	c := testutil.NewCache(b)
+	body := []byte(`{"key": "key", "value": "val"}`)
+
+	b.Run("http", func(b *testing.B) {
+		benchmarkHTTP(b, c, func(url string) (*http.Request, error) {
+			return http.NewRequest(http.MethodPost, url+"/set", bytes.NewReader(body))
+		})
+	})
+
+	b.Run("binary", func(b *testing.B) {
+		benchmarkBinary(b, c, func(client *wire.Client) error {
+			return client.Set(context.Background(), "key", []byte("val"))
+		})
+	})
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/client"
+	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
+	"github.com/MojtabaArezoomand/lru_cache/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient(t *testing.T) {
+	c := testutil.NewCache(t)
+	cl, err := client.New(testutil.ServeHTTP(t, newRouter(newApp(c))))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = cl.Get(ctx, "key")
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+
+	assert.NoError(t, cl.Set(ctx, "key", map[string]any{"a": 1}))
+	item, err := cl.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, client.Item{Key: "key", Value: map[string]any{"a": 1.0}}, item)
+
+	// Keys are escaped.
+	assert.NoError(t, cl.SetWithTTL(ctx, "a key?", "val", 90*time.Second))
+	item, err = cl.Get(ctx, "a key?")
+	assert.NoError(t, err)
+	assert.Equal(t, "val", item.Value)
+	assert.WithinDuration(t, time.Now().Add(90*time.Second), item.ExpiresAt, time.Second)
+
+	// A zero ttl never expires.
+	assert.NoError(t, cl.SetWithTTL(ctx, "forever", 1, 0))
+	cached, err := c.GetItem(ctx, "forever")
+	assert.NoError(t, err)
+	assert.True(t, cached.ExpiresAt.IsZero())
+
+	stats, err := cl.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, c.Stats(), stats)
+
+	assert.NoError(t, cl.Delete(ctx, "key"))
+	assert.ErrorIs(t, cl.Delete(ctx, "key"), cache.ErrNotFound)
+
+	assert.NoError(t, cl.Flush(ctx))
+	assert.Zero(t, c.Stats().Size)
+
+	err = cl.Set(ctx, "", "val")
+	assert.Equal(t, &client.Error{StatusCode: http.StatusBadRequest, Detail: "key is required"}, err)
+}
+
+func TestClientKeys(t *testing.T) {
+	cl, err := client.New(testutil.ServeHTTP(t, newRouter(newApp(testutil.NewCache(t)))))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	// Keys with slashes, dots or escapes are a single path segment.
+	for _, key := range []string{"a/b", "..", ".", "x/../y", "a key?", "100%", "a%2Fb", "#fragment"} {
+		assert.NoError(t, cl.Set(ctx, key, key), key)
+
+		item, err := cl.Get(ctx, key)
+		assert.NoError(t, err, key)
+		assert.Equal(t, key, item.Key, key)
+		assert.Equal(t, key, item.Value, key)
+
+		assert.NoError(t, cl.Delete(ctx, key), key)
+		_, err = cl.Get(ctx, key)
+		assert.ErrorIs(t, err, cache.ErrNotFound, key)
+	}
+}
+
+func TestClientDefaultTTL(t *testing.T) {
+	c := testutil.NewCache(t, cache.WithDefaultTTL(time.Minute))
+	cl, err := client.New(testutil.ServeHTTP(t, newRouter(newApp(c))))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, cl.Set(ctx, "key", "val"))
+	item, err := cl.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), item.ExpiresAt, time.Second)
+}
+
+func TestClientTooLarge(t *testing.T) {
+	c := testutil.NewCache(t, cache.WithMaxBytes(16), cache.WithSizer(cache.JSONSizer))
+	cl, err := client.New(testutil.ServeHTTP(t, newRouter(newApp(c))))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, cl.Set(context.Background(), "key", "a value which is too large"), cache.ErrTooLarge)
+}
+
+func TestClientBatch(t *testing.T) {
+	c := testutil.NewCache(t)
+	cl, err := client.New(testutil.ServeHTTP(t, newRouter(newApp(c))))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	vals := make(map[string]any)
+	keys := []string{"missing"}
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprint("key", i)
+		vals[key] = float64(i)
+		keys = append(keys, key)
+	}
+
+	assert.NoError(t, cl.SetMany(ctx, vals))
+	assert.Equal(t, uint64(20), c.Stats().Size)
+
+	// Keys which don't exist are left out.
+	items, err := cl.GetMany(ctx, keys)
+	assert.NoError(t, err)
+	assert.Len(t, items, 20)
+	for key, val := range vals {
+		assert.Equal(t, val, items[key].Value, key)
+	}
+
+	assert.NoError(t, cl.DeleteMany(ctx, keys))
+	assert.Zero(t, c.Stats().Size)
+
+	// The first error fails the batch.
+	cl, err = client.New(testutil.ServeHTTP(t, newRouter(newApp(testutil.NewCache(t, cache.WithStore(failingStore{}))))))
+	assert.NoError(t, err)
+	_, err = cl.GetMany(ctx, keys)
+	assert.Equal(t, &client.Error{StatusCode: http.StatusInternalServerError, Detail: "internal server error"}, err)
+}
+
+func TestClientRetries(t *testing.T) {
+	router := newRouter(newApp(testutil.NewCache(t)))
+
+	// Every other request fails as if a proxy in front of the server couldn't reach it.
+	var calls int64
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&calls, 1)%2 == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		router.ServeHTTP(w, r)
+	})
+
+	url := testutil.ServeHTTP(t, handler)
+	cl, err := client.New(url, client.WithBackoff(time.Millisecond, time.Millisecond))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, cl.Set(ctx, "key", "val"))
+	item, err := cl.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "val", item.Value)
+	assert.Equal(t, int64(4), atomic.LoadInt64(&calls))
+
+	cl, err = client.New(url, client.WithRetries(0))
+	assert.NoError(t, err)
+	atomic.StoreInt64(&calls, 0)
+	err = cl.Flush(ctx)
+	assert.Equal(t, &client.Error{StatusCode: http.StatusBadGateway, Detail: "Bad Gateway"}, err)
+}
@@ -1,12 +1,19 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
+	"github.com/MojtabaArezoomand/lru_cache/internal/metrics"
 	"github.com/gorilla/mux"
 )
 
@@ -14,55 +21,156 @@ type (
 	// App is the type for handling handlers.
 	App struct {
 		cache               *cache.Cache
+		metrics             *metrics.Registry
+		requestDuration     *metrics.HistogramVec
 		NotFoundResp        []byte
 		TimeoutResp         []byte
 		InternalServerError []byte
 		KeyEmptyResp        []byte
+		InvalidKeyResp      []byte
+		InvalidTTLResp      []byte
+		TooLargeResp        []byte
 		OKResp              []byte
 	}
 
 	// GetResponse is the response of get handler.
+	// TTL and ExpiresAt are only present for keys which expire, and Stale for stale keys.
 	GetResponse struct {
 		Key   string `json:"key"`
 		Value any    `json:"value"`
+		// TTL is the remaining lifetime of the key in seconds.
+		TTL       *float64   `json:"ttl,omitempty"`
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+		// Stale is true if the value is being refreshed from the store in the background.
+		Stale bool `json:"stale,omitempty"`
 	}
 
 	// SetRequest is the request of set handler.
 	SetRequest struct {
 		Key   string `json:"key"`
 		Value any    `json:"value"`
+		// TTL is optional, the cache's default ttl is used if it's not given.
+		TTL *Duration `json:"ttl"`
 	}
+
+	// Duration is a time.Duration which is unmarshaled from either
+	// a duration string like "1m30s" or a number of seconds.
+	Duration time.Duration
 )
 
-// newApp returns a new app.
-func newApp() *App {
+// errInvalidTTL is returned when the ttl of set request can't be parsed.
+var errInvalidTTL = errors.New("invalid ttl")
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidTTL, err)
+	}
+
+	switch val := v.(type) {
+	case nil:
+		*d = 0
+	case float64:
+		if val < 0 || val > math.MaxInt64/float64(time.Second) {
+			return fmt.Errorf("%w: %v is out of range", errInvalidTTL, val)
+		}
+		*d = Duration(val * float64(time.Second))
+	case string:
+		dur, err := time.ParseDuration(val)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errInvalidTTL, err)
+		}
+		if dur < 0 {
+			return fmt.Errorf("%w: %s is negative", errInvalidTTL, val)
+		}
+		*d = Duration(dur)
+	default:
+		return fmt.Errorf("%w: %s", errInvalidTTL, b)
+	}
+
+	return nil
+}
+
+// newApp returns a new app which serves the given cache.
+func newApp(c *cache.Cache) *App {
+	requestDuration := metrics.NewHistogramVec(
+		"lrucache_http_request_duration_seconds",
+		"Latency of HTTP requests in seconds.",
+		metrics.DefaultBuckets,
+		"route", "method", "code",
+	)
+
 	app := App{
-		cache:               cache.NewCache(),
+		cache:               c,
+		metrics:             metrics.NewRegistry(),
+		requestDuration:     requestDuration,
 		NotFoundResp:        []byte(`{"detail": "not found"}`),
 		TimeoutResp:         []byte(`{"detail": "timeout"}`),
 		InternalServerError: []byte(`{"detail": "internal server error"}`),
 		KeyEmptyResp:        []byte(`{"detail": "key is required"}`),
+		InvalidKeyResp:      []byte(`{"detail": "invalid key"}`),
+		InvalidTTLResp:      []byte(`{"detail": "invalid ttl"}`),
+		TooLargeResp:        []byte(`{"detail": "value is too large"}`),
 		OKResp:              []byte(`{"message": "ok"}`),
 	}
+
+	app.metrics.Register(cacheCollector{cache: c}, app.requestDuration)
+
 	return &app
 }
 
+// writeCacheError writes the response of an unexpected cache error.
+// Done contexts are timeouts, other errors come from the cache's store.
+func (app *App) writeCacheError(w http.ResponseWriter, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		w.WriteHeader(http.StatusGatewayTimeout)
+		w.Write(app.TimeoutResp)
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write(app.InternalServerError)
+}
+
+// pathKey returns the unescaped key of the request's path.
+// The router matches escaped paths, so keys may contain slashes and dots.
+func (app *App) pathKey(w http.ResponseWriter, r *http.Request) (string, bool) {
+	key, err := url.PathUnescape(mux.Vars(r)["key"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(app.InvalidKeyResp)
+		log.Println("invalid key, reason:", err)
+		return "", false
+	}
+
+	return key, true
+}
+
 // Get fetches a key from cache.
 func (app *App) Get(w http.ResponseWriter, r *http.Request) {
-	key := mux.Vars(r)["key"]
-
 	w.Header().Set("Content-Type", "application/json")
 
-	if v, err := app.cache.Get(r.Context(), key); err == cache.ErrNotFound {
+	key, ok := app.pathKey(w, r)
+	if !ok {
+		return
+	}
+
+	if item, err := app.cache.GetItem(r.Context(), key); err == cache.ErrNotFound {
 		w.WriteHeader(http.StatusNotFound)
 		w.Write(app.NotFoundResp)
 		log.Println("not found")
 	} else if err != nil {
-		w.WriteHeader(http.StatusGatewayTimeout)
-		w.Write(app.TimeoutResp)
+		app.writeCacheError(w, err)
 		log.Println("error in fetching key, reason:", err)
 	} else {
-		resp := GetResponse{Key: key, Value: v}
+		resp := GetResponse{Key: key, Value: item.Value, Stale: item.Stale}
+		if !item.ExpiresAt.IsZero() {
+			ttl := math.Max(item.ExpiresAt.Sub(app.cache.Now()).Truncate(time.Millisecond).Seconds(), 0)
+			resp.TTL = &ttl
+			resp.ExpiresAt = &item.ExpiresAt
+		}
+
 		respBytes, err := json.Marshal(resp)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
@@ -92,7 +200,12 @@ func (app *App) Set(w http.ResponseWriter, r *http.Request) {
 
 	var req SetRequest
 	err = json.Unmarshal(body, &req)
-	if err != nil {
+	if errors.Is(err, errInvalidTTL) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(app.InvalidTTLResp)
+		log.Println("invalid ttl, reason:", err)
+		return
+	} else if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write(app.InternalServerError)
 		log.Println("error in unmarshaling request, reason:", err)
@@ -106,10 +219,18 @@ func (app *App) Set(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.cache.Set(r.Context(), req.Key, req.Value)
-	if err != nil {
-		w.WriteHeader(http.StatusGatewayTimeout)
-		w.Write(app.TimeoutResp)
+	if req.TTL != nil {
+		err = app.cache.SetWithTTL(r.Context(), req.Key, req.Value, time.Duration(*req.TTL))
+	} else {
+		err = app.cache.Set(r.Context(), req.Key, req.Value)
+	}
+	if err == cache.ErrTooLarge {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		w.Write(app.TooLargeResp)
+		log.Println("value was too large")
+		return
+	} else if err != nil {
+		app.writeCacheError(w, err)
 		log.Println("error in setting key, reason:", err)
 		return
 	}
@@ -119,13 +240,52 @@ func (app *App) Set(w http.ResponseWriter, r *http.Request) {
 	log.Println("SET: ok")
 }
 
+// Delete removes a key from cache.
+func (app *App) Delete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	key, ok := app.pathKey(w, r)
+	if !ok {
+		return
+	}
+
+	if err := app.cache.Delete(r.Context(), key); err == cache.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(app.NotFoundResp)
+		log.Println("not found")
+	} else if err != nil {
+		app.writeCacheError(w, err)
+		log.Println("error in deleting key, reason:", err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+		w.Write(app.OKResp)
+		log.Println("DELETE: ok")
+	}
+}
+
+// Stats returns the cache's statistics.
+func (app *App) Stats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	respBytes, err := json.Marshal(app.cache.Stats())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(app.InternalServerError)
+		log.Println("error in marshaling response, reason:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBytes)
+	log.Println("STATS: ok")
+}
+
 // Flush flushes the whole cache.
 func (app *App) Flush(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if err := app.cache.Flush(r.Context()); err != nil {
-		w.WriteHeader(http.StatusGatewayTimeout)
-		w.Write(app.TimeoutResp)
+		app.writeCacheError(w, err)
 		log.Println("error in flushing cache, reason:", err)
 	} else {
 		w.WriteHeader(http.StatusOK)
@@ -3,15 +3,22 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
+	"github.com/MojtabaArezoomand/lru_cache/internal/testutil"
+	"github.com/MojtabaArezoomand/lru_cache/lru"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mockReader struct {
@@ -21,6 +28,11 @@ func (r mockReader) Read(p []byte) (n int, err error) {
 	return 0, errors.New("read body error")
 }
 
+// newTestRouter returns a new router backed by a new cache.
+func newTestRouter(t *testing.T) *mux.Router {
+	return newRouter(newApp(testutil.NewCache(t)))
+}
+
 func setToCache(t *testing.T, r *mux.Router, key string, value any) {
 	rr := httptest.NewRecorder()
 
@@ -36,7 +48,7 @@ func setToCache(t *testing.T, r *mux.Router, key string, value any) {
 }
 
 func TestGet(t *testing.T) {
-	r := newRouter()
+	r := newTestRouter(t)
 
 	setToCache(t, r, "10", 10)
 
@@ -72,7 +84,7 @@ func TestGet(t *testing.T) {
 }
 
 func TestSet(t *testing.T) {
-	r := newRouter()
+	r := newTestRouter(t)
 
 	testcases := []struct {
 		name       string
@@ -84,6 +96,13 @@ func TestSet(t *testing.T) {
 		{name: "unmarshal_error", statusCode: http.StatusInternalServerError, resp: []byte(`{"detail": "internal server error"}`), body: []byte(`{"key":not_str,"value":true}`)},
 		{name: "body_error", statusCode: http.StatusInternalServerError, resp: []byte(`{"detail": "internal server error"}`), body: nil},
 		{name: "empty_key", statusCode: http.StatusBadRequest, resp: []byte(`{"detail": "key is required"}`), body: []byte(`{"key":"","value":true}`)},
+		{name: "ttl_seconds", statusCode: http.StatusOK, resp: []byte(`{"message": "ok"}`), body: []byte(`{"key":"ttl","value":true,"ttl":1.5}`)},
+		{name: "ttl_string", statusCode: http.StatusOK, resp: []byte(`{"message": "ok"}`), body: []byte(`{"key":"ttl","value":true,"ttl":"1m30s"}`)},
+		{name: "ttl_null", statusCode: http.StatusOK, resp: []byte(`{"message": "ok"}`), body: []byte(`{"key":"ttl","value":true,"ttl":null}`)},
+		{name: "ttl_invalid_string", statusCode: http.StatusBadRequest, resp: []byte(`{"detail": "invalid ttl"}`), body: []byte(`{"key":"ttl","value":true,"ttl":"soon"}`)},
+		{name: "ttl_negative", statusCode: http.StatusBadRequest, resp: []byte(`{"detail": "invalid ttl"}`), body: []byte(`{"key":"ttl","value":true,"ttl":-1}`)},
+		{name: "ttl_negative_string", statusCode: http.StatusBadRequest, resp: []byte(`{"detail": "invalid ttl"}`), body: []byte(`{"key":"ttl","value":true,"ttl":"-1s"}`)},
+		{name: "ttl_invalid_type", statusCode: http.StatusBadRequest, resp: []byte(`{"detail": "invalid ttl"}`), body: []byte(`{"key":"ttl","value":true,"ttl":[1]}`)},
 	}
 
 	for _, tc := range testcases {
@@ -114,20 +133,231 @@ func TestSet(t *testing.T) {
 	}
 }
 
+func TestGetTTL(t *testing.T) {
+	r := newTestRouter(t)
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/set", bytes.NewReader([]byte(`{"key":"ttl","value":1,"ttl":"1h"}`)))
+	assert.NoError(t, err)
+
+	before := time.Now()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/get/ttl", nil)
+	assert.NoError(t, err)
+
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var resp GetResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	assert.Equal(t, "ttl", resp.Key)
+	assert.EqualValues(t, 1, resp.Value)
+	if assert.NotNil(t, resp.TTL) {
+		assert.InDelta(t, time.Hour.Seconds(), *resp.TTL, 1)
+	}
+	if assert.NotNil(t, resp.ExpiresAt) {
+		assert.WithinDuration(t, before.Add(time.Hour), *resp.ExpiresAt, time.Second)
+	}
+	assert.False(t, resp.Stale)
+	assert.NotContains(t, rr.Body.String(), "stale")
+}
+
+func TestGetTTLClock(t *testing.T) {
+	now := time.Unix(1000000000, 0)
+	r := newRouter(newApp(testutil.NewCache(t, cache.WithClock(func() time.Time { return now }))))
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/set", bytes.NewReader([]byte(`{"key":"ttl","value":1,"ttl":100}`)))
+	assert.NoError(t, err)
+
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// The ttl is relative to the cache's clock.
+	rr = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/get/ttl", nil)
+	assert.NoError(t, err)
+
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var resp GetResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	if assert.NotNil(t, resp.TTL) {
+		assert.Equal(t, 100.0, *resp.TTL)
+	}
+	if assert.NotNil(t, resp.ExpiresAt) {
+		assert.True(t, now.Add(100*time.Second).Equal(*resp.ExpiresAt))
+	}
+}
+
+func TestGetStale(t *testing.T) {
+	store := lru.NewMemoryStore[string, any]()
+	c, err := cache.NewCache(cache.WithStore(store), cache.WithRefreshAfter(time.Nanosecond), cache.WithCleanupInterval(0))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	r := newRouter(newApp(c))
+
+	setToCache(t, r, "key", 1)
+	time.Sleep(time.Millisecond)
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/get/key", nil)
+	assert.NoError(t, err)
+
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"key":"key","value":1,"stale":true}`, rr.Body.String())
+}
+
+func TestSetDefaultTTL(t *testing.T) {
+	c, err := cache.NewCache(cache.WithDefaultTTL(time.Hour), cache.WithCleanupInterval(0))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	r := newRouter(newApp(c))
+
+	setToCache(t, r, "default", 1)
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/set", bytes.NewReader([]byte(`{"key":"forever","value":2,"ttl":0}`)))
+	assert.NoError(t, err)
+
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	item, err := c.GetItem(context.Background(), "default")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), item.ExpiresAt, time.Second)
+
+	item, err = c.GetItem(context.Background(), "forever")
+	assert.NoError(t, err)
+	assert.True(t, item.ExpiresAt.IsZero())
+}
+
+func TestSetTooLarge(t *testing.T) {
+	c, err := cache.NewCache(cache.WithMaxBytes(16), cache.WithSizer(cache.JSONSizer), cache.WithCleanupInterval(0))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	r := newRouter(newApp(c))
+
+	setToCache(t, r, "small", 1)
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/set", bytes.NewReader([]byte(`{"key":"large","value":"larger than 16 bytes"}`)))
+	assert.NoError(t, err)
+
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Equal(t, []byte(`{"detail": "value is too large"}`), rr.Body.Bytes())
+}
+
+// failingStore is a store whose operations always fail.
+type failingStore struct{}
+
+func (failingStore) Load(ctx context.Context, key string) (any, error) {
+	return nil, errors.New("store is down")
+}
+
+func (failingStore) Save(ctx context.Context, key string, val any) error {
+	return errors.New("store is down")
+}
+
+func (failingStore) Delete(ctx context.Context, key string) error {
+	return errors.New("store is down")
+}
+
+func TestStoreError(t *testing.T) {
+	c, err := cache.NewCache(cache.WithStore(failingStore{}), cache.WithCleanupInterval(0))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	r := newRouter(newApp(c))
+
+	testCases := []struct {
+		method string
+		url    string
+		body   []byte
+	}{
+		{method: http.MethodGet, url: "/get/key"},
+		{method: http.MethodPost, url: "/set", body: []byte(`{"key":"key","value":1}`)},
+		{method: http.MethodDelete, url: "/keys/key"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(tc.method, tc.url, bytes.NewReader(tc.body))
+			assert.NoError(t, err)
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+			assert.Equal(t, []byte(`{"detail": "internal server error"}`), rr.Body.Bytes())
+		})
+	}
+}
+
+func TestDurationUnmarshalJSON(t *testing.T) {
+	testcases := []struct {
+		name string
+		data string
+		want time.Duration
+		err  bool
+	}{
+		{name: "seconds", data: `30`, want: 30 * time.Second},
+		{name: "fractional_seconds", data: `0.25`, want: 250 * time.Millisecond},
+		{name: "string", data: `"1h2m"`, want: time.Hour + 2*time.Minute},
+		{name: "null", data: `null`, want: 0},
+		{name: "negative", data: `-5`, err: true},
+		{name: "too_large", data: `1e300`, err: true},
+		{name: "bad_string", data: `"forever"`, err: true},
+		{name: "bool", data: `true`, err: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var d Duration
+			err := json.Unmarshal([]byte(tc.data), &d)
+
+			if tc.err {
+				assert.ErrorIs(t, err, errInvalidTTL)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, time.Duration(d))
+		})
+	}
+}
+
 func TestNewApp(t *testing.T) {
-	app := newApp()
+	c := testutil.NewCache(t)
+	app := newApp(c)
 
 	assert.NotNil(t, app)
-	assert.NotNil(t, app.cache)
+	assert.Equal(t, c, app.cache)
+	assert.NotNil(t, app.metrics)
+	assert.NotNil(t, app.requestDuration)
 	assert.Equal(t, []byte(`{"detail": "internal server error"}`), app.InternalServerError)
 	assert.Equal(t, []byte(`{"detail": "key is required"}`), app.KeyEmptyResp)
+	assert.Equal(t, []byte(`{"detail": "invalid ttl"}`), app.InvalidTTLResp)
+	assert.Equal(t, []byte(`{"detail": "value is too large"}`), app.TooLargeResp)
 	assert.Equal(t, []byte(`{"detail": "not found"}`), app.NotFoundResp)
 	assert.Equal(t, []byte(`{"message": "ok"}`), app.OKResp)
 	assert.Equal(t, []byte(`{"detail": "timeout"}`), app.TimeoutResp)
 }
 
 func TestTimeout(t *testing.T) {
-	r := newRouter()
+	r := newTestRouter(t)
 
 	testcases := []struct {
 		name   string
@@ -137,6 +367,7 @@ func TestTimeout(t *testing.T) {
 		{name: "get", reqUrl: "/get/10", method: http.MethodGet},
 		{name: "set", reqUrl: "/set", method: http.MethodPost},
 		{name: "flush", reqUrl: "/flush", method: http.MethodGet},
+		{name: "delete", reqUrl: "/keys/10", method: http.MethodDelete},
 	}
 
 	for _, tc := range testcases {
@@ -172,7 +403,7 @@ func TestTimeout(t *testing.T) {
 }
 
 func TestFlush(t *testing.T) {
-	r := newRouter()
+	r := newTestRouter(t)
 
 	setToCache(t, r, "10", 10)
 
@@ -206,3 +437,94 @@ func TestFlush(t *testing.T) {
 		})
 	}
 }
+
+func TestFlushLogError(t *testing.T) {
+	l, err := lru.OpenLog[string, any](filepath.Join(t.TempDir(), "cache.log"), lru.FsyncNever)
+	require.NoError(t, err)
+
+	r := newRouter(newApp(testutil.NewCache(t, cache.WithLog(l))))
+
+	// Flushes which can't be logged are internal errors rather than timeouts.
+	require.NoError(t, l.Close())
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/flush", nil)
+	assert.NoError(t, err)
+
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, []byte(`{"detail": "internal server error"}`), rr.Body.Bytes())
+}
+
+func TestDelete(t *testing.T) {
+	r := newTestRouter(t)
+
+	setToCache(t, r, "10", 10)
+
+	testcases := []struct {
+		name       string
+		statusCode int
+		method     string
+		reqUrl     string
+		resp       []byte
+	}{
+		{name: "get_ok", statusCode: http.StatusOK, method: http.MethodGet, reqUrl: "/get/10", resp: []byte(`{"key":"10","value":10}`)},
+		{name: "ok", statusCode: http.StatusOK, method: http.MethodDelete, reqUrl: "/keys/10", resp: []byte(`{"message": "ok"}`)},
+		{name: "get_not_found", statusCode: http.StatusNotFound, method: http.MethodGet, reqUrl: "/get/10", resp: []byte(`{"detail": "not found"}`)},
+		{name: "not_found", statusCode: http.StatusNotFound, method: http.MethodDelete, reqUrl: "/keys/10", resp: []byte(`{"detail": "not found"}`)},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+
+			req, err := http.NewRequest(tc.method, tc.reqUrl, nil)
+			assert.NoError(t, err)
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.statusCode, rr.Code)
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+			resp, err := ioutil.ReadAll(rr.Body)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.resp, resp)
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	r := newTestRouter(t)
+
+	setToCache(t, r, "10", 10)
+
+	for _, reqUrl := range []string{"/get/10", "/get/10", "/get/not_found"} {
+		req, err := http.NewRequest(http.MethodGet, reqUrl, nil)
+		assert.NoError(t, err)
+
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	rr := httptest.NewRecorder()
+
+	req, err := http.NewRequest(http.MethodGet, "/stats", nil)
+	assert.NoError(t, err)
+
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"hits": 2,
+		"misses": 1,
+		"sets": 1,
+		"overwrites": 0,
+		"deletes": 0,
+		"evictions": 0,
+		"expirations": 0,
+		"size": 1,
+		"bytes": 0
+	}`, rr.Body.String())
+}
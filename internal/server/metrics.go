@@ -0,0 +1,79 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
+	"github.com/MojtabaArezoomand/lru_cache/internal/metrics"
+	"github.com/gorilla/mux"
+)
+
+type (
+	// cacheCollector exposes the cache's statistics as metrics.
+	cacheCollector struct {
+		cache *cache.Cache
+	}
+
+	// statusRecorder records the status code written to the response.
+	statusRecorder struct {
+		http.ResponseWriter
+		status int
+	}
+)
+
+// Collect implements metrics.Collector interface.
+func (c cacheCollector) Collect(w *metrics.Writer) {
+	stats := c.cache.Stats()
+
+	counters := []struct {
+		name  string
+		help  string
+		value uint64
+	}{
+		{name: "lrucache_hits_total", help: "Number of lookups which found the key.", value: stats.Hits},
+		{name: "lrucache_misses_total", help: "Number of lookups which didn't find the key.", value: stats.Misses},
+		{name: "lrucache_sets_total", help: "Number of stored key-values, including overwrites.", value: stats.Sets},
+		{name: "lrucache_overwrites_total", help: "Number of overwritten keys.", value: stats.Overwrites},
+		{name: "lrucache_deletes_total", help: "Number of deleted keys.", value: stats.Deletes},
+		{name: "lrucache_evictions_total", help: "Number of keys evicted to respect the capacity or max bytes.", value: stats.Evictions},
+		{name: "lrucache_expirations_total", help: "Number of expired keys which were removed.", value: stats.Expirations},
+	}
+
+	for _, c := range counters {
+		w.Family(c.name, c.help, metrics.Counter)
+		w.Sample(c.name, float64(c.value))
+	}
+
+	w.Family("lrucache_size", "Number of keys in the cache.", metrics.Gauge)
+	w.Sample("lrucache_size", float64(stats.Size))
+
+	w.Family("lrucache_bytes", "Total size of the keys in the cache in bytes.", metrics.Gauge)
+	w.Sample("lrucache_bytes", float64(stats.Bytes))
+}
+
+// WriteHeader records the status code before writing it.
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// instrument is a middleware which observes the latency of requests per route.
+func (app *App) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if tmpl, err := current.GetPathTemplate(); err == nil {
+				route = tmpl
+			}
+		}
+
+		app.requestDuration.Observe(time.Since(start).Seconds(), route, r.Method, strconv.Itoa(rec.status))
+	})
+}
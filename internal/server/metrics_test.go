@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	r := newTestRouter(t)
+
+	setToCache(t, r, "10", 10)
+
+	for _, reqUrl := range []string{"/get/10", "/get/not_found"} {
+		req, err := http.NewRequest(http.MethodGet, reqUrl, nil)
+		assert.NoError(t, err)
+
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	rr := httptest.NewRecorder()
+
+	req, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	assert.NoError(t, err)
+
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, metrics.ContentType, rr.Header().Get("Content-Type"))
+
+	body := rr.Body.String()
+
+	for _, line := range []string{
+		"# TYPE lrucache_hits_total counter",
+		"lrucache_hits_total 1",
+		"lrucache_misses_total 1",
+		"lrucache_sets_total 1",
+		"lrucache_evictions_total 0",
+		"# TYPE lrucache_size gauge",
+		"lrucache_size 1",
+		"# TYPE lrucache_http_request_duration_seconds histogram",
+		`lrucache_http_request_duration_seconds_count{route="/get/{key}",method="GET",code="200"} 1`,
+		`lrucache_http_request_duration_seconds_count{route="/get/{key}",method="GET",code="404"} 1`,
+		`lrucache_http_request_duration_seconds_count{route="/set",method="POST",code="200"} 1`,
+		`lrucache_http_request_duration_seconds_bucket{route="/set",method="POST",code="200",le="+Inf"} 1`,
+	} {
+		assert.Contains(t, strings.Split(body, "\n"), line)
+	}
+}
+
+func TestStatusRecorder(t *testing.T) {
+	rr := httptest.NewRecorder()
+	rec := &statusRecorder{ResponseWriter: rr, status: http.StatusOK}
+
+	rec.WriteHeader(http.StatusTeapot)
+
+	assert.Equal(t, http.StatusTeapot, rec.status)
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+}
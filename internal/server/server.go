@@ -6,23 +6,32 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
 	"github.com/MojtabaArezoomand/lru_cache/internal/config"
+	"github.com/MojtabaArezoomand/lru_cache/internal/memcache"
+	"github.com/MojtabaArezoomand/lru_cache/internal/netserver"
+	"github.com/MojtabaArezoomand/lru_cache/internal/resp"
+	"github.com/MojtabaArezoomand/lru_cache/lru"
 	"github.com/gorilla/mux"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
-// newRouter initializes a new router.
-func newRouter() *mux.Router {
-	r := mux.NewRouter()
-
-	app := newApp()
+// newRouter initializes a new router for the app's handlers.
+func newRouter(app *App) *mux.Router {
+	// Keys are matched escaped and their paths aren't cleaned, so a key like "a/b" or ".." is a single path segment.
+	r := mux.NewRouter().UseEncodedPath().SkipClean(true)
+	r.Use(app.instrument)
 
 	r.HandleFunc("/get/{key}", app.Get).Methods(http.MethodGet)
 	r.HandleFunc("/set", app.Set).Methods(http.MethodPost)
 	r.HandleFunc("/flush", app.Flush).Methods(http.MethodGet)
+	r.HandleFunc("/keys/{key}", app.Delete).Methods(http.MethodDelete)
+	r.HandleFunc("/stats", app.Stats).Methods(http.MethodGet)
+	r.Handle("/metrics", app.metrics).Methods(http.MethodGet)
 
 	return r
 }
@@ -34,7 +43,67 @@ func RunServer() {
 		panic(err)
 	}
 
-	r := newRouter()
+	cacheOpts, err := cache.EnvOptions()
+	if err != nil {
+		panic(err)
+	}
+
+	var logCfg config.LogConfig
+	if err := cleanenv.ReadEnv(&logCfg); err != nil {
+		panic(err)
+	}
+
+	var opLog *lru.Log[string, any]
+	if logCfg.Path != "" {
+		opLog, err = lru.OpenLog[string, any](logCfg.Path, lru.FsyncPolicy(logCfg.Fsync))
+		if err != nil {
+			panic(err)
+		}
+		// The log is closed after the cache, which is closed by the next defer.
+		defer opLog.Close()
+
+		cacheOpts = append(cacheOpts, cache.WithLog(opLog))
+	}
+
+	// The log is at least as recent as the snapshot, so the snapshot is only restored into a new log.
+	restore := opLog == nil || opLog.Size() == 0
+
+	c, err := cache.NewCache(cacheOpts...)
+	if err != nil {
+		panic(err)
+	}
+	defer c.Close()
+
+	var snapshotCfg config.SnapshotConfig
+	if err := cleanenv.ReadEnv(&snapshotCfg); err != nil {
+		panic(err)
+	}
+
+	// The server starts with a cold cache if the snapshot can't be restored.
+	if snapshotCfg.Path != "" && restore {
+		if err := restoreSnapshot(context.Background(), c, snapshotCfg.Path); err != nil {
+			log.Println("Couldn't restore the snapshot:", err)
+		}
+
+		// The restored keys are written to the log, which doesn't have them.
+		if err := c.CompactLog(context.Background()); err != nil {
+			log.Println("Couldn't compact the log:", err)
+		}
+	}
+
+	snapshotCtx, stopSnapshots := context.WithCancel(context.Background())
+	defer stopSnapshots()
+
+	var snapshots sync.WaitGroup
+	if snapshotCfg.Path != "" && snapshotCfg.Interval > 0 {
+		snapshots.Add(1)
+		go func() {
+			defer snapshots.Done()
+			snapshotEvery(snapshotCtx, c, snapshotCfg.Path, snapshotCfg.Interval)
+		}()
+	}
+
+	r := newRouter(newApp(c))
 
 	srv := &http.Server{
 		Addr:         cfg.Address,
@@ -49,6 +118,36 @@ func RunServer() {
 		}
 	}()
 
+	var respSrv *resp.Server
+	if cfg.RESPAddress != "" {
+		respSrv = resp.NewServer(c)
+		go func() {
+			if err := respSrv.ListenAndServe(cfg.RESPAddress); err != nil && err != resp.ErrServerClosed {
+				log.Println(err)
+			}
+		}()
+	}
+
+	var memcacheSrv *memcache.Server
+	if cfg.MemcachedAddress != "" {
+		memcacheSrv = memcache.NewServer(c)
+		go func() {
+			if err := memcacheSrv.ListenAndServe(cfg.MemcachedAddress); err != nil && err != memcache.ErrServerClosed {
+				log.Println(err)
+			}
+		}()
+	}
+
+	var binarySrv *binaryServer
+	if cfg.BinaryAddress != "" {
+		binarySrv = newBinaryServer(c)
+		go func() {
+			if err := binarySrv.ListenAndServe(cfg.BinaryAddress); err != nil && err != netserver.ErrServerClosed {
+				log.Println(err)
+			}
+		}()
+	}
+
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGINT, syscall.SIGQUIT)
 	<-sig
@@ -60,4 +159,24 @@ func RunServer() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Println("Couldn't shutdown the server:", err)
 	}
+
+	if respSrv != nil {
+		respSrv.Close()
+	}
+	if memcacheSrv != nil {
+		memcacheSrv.Close()
+	}
+	if binarySrv != nil {
+		binarySrv.Close()
+	}
+
+	stopSnapshots()
+	snapshots.Wait()
+
+	if snapshotCfg.Path != "" {
+		log.Println("Saving the snapshot...")
+		if err := saveSnapshot(context.Background(), c, snapshotCfg.Path); err != nil {
+			log.Println("Couldn't save the snapshot:", err)
+		}
+	}
 }
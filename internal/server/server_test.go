@@ -13,7 +13,7 @@ import (
 )
 
 func TestNewRouter(t *testing.T) {
-	r := newRouter()
+	r := newTestRouter(t)
 
 	testcases := []struct {
 		name   string
@@ -23,6 +23,8 @@ func TestNewRouter(t *testing.T) {
 		{name: "get", reqUrl: "/get/10", method: http.MethodGet},
 		{name: "set", reqUrl: "/set", method: http.MethodPost},
 		{name: "flush", reqUrl: "/flush", method: http.MethodGet},
+		{name: "delete", reqUrl: "/keys/10", method: http.MethodDelete},
+		{name: "stats", reqUrl: "/stats", method: http.MethodGet},
 	}
 
 	for _, tc := range testcases {
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
+)
+
+// saveSnapshot writes the cache's snapshot to the file at path.
+// The snapshot is written to a temporary file first, so the previous snapshot is kept if writing fails.
+func saveSnapshot(ctx context.Context, c *cache.Cache, path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := c.Snapshot(ctx, f); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// restoreSnapshot loads the snapshot at path into the cache, a missing snapshot isn't an error.
+func restoreSnapshot(ctx context.Context, c *cache.Cache, path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.Restore(ctx, f)
+}
+
+// snapshotEvery saves the cache's snapshot every interval until ctx is done.
+func snapshotEvery(ctx context.Context, c *cache.Cache, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := saveSnapshot(ctx, c, path); err != nil {
+				log.Println("error in saving snapshot, reason:", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	ctx := context.Background()
+
+	// A missing snapshot leaves the cache cold.
+	c := testutil.NewCache(t)
+	assert.NoError(t, restoreSnapshot(ctx, c, path))
+	assert.Zero(t, c.Stats().Size)
+
+	assert.NoError(t, c.Set(ctx, "a", "val"))
+	assert.NoError(t, c.SetWithTTL(ctx, "b", []any{1.0, "val"}, time.Hour))
+	assert.NoError(t, saveSnapshot(ctx, c, path))
+
+	restored := testutil.NewCache(t)
+	assert.NoError(t, restoreSnapshot(ctx, restored, path))
+
+	val, err := restored.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "val", val)
+
+	item, err := restored.GetItem(ctx, "b")
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1.0, "val"}, item.Value)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), item.ExpiresAt, time.Second)
+
+	// Only the snapshot is left in the directory.
+	files, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	assert.NoError(t, os.WriteFile(path, []byte("invalid"), 0o644))
+	assert.Error(t, restoreSnapshot(ctx, restored, path))
+
+	assert.Error(t, saveSnapshot(ctx, c, filepath.Join(path, "not_a_dir", "cache.snapshot")))
+}
+
+func TestSnapshotEvery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	c := testutil.NewCache(t)
+	assert.NoError(t, c.Set(context.Background(), "key", "val"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		snapshotEvery(ctx, c, path, time.Millisecond)
+	}()
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+}
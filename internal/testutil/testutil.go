@@ -0,0 +1,77 @@
+// Package testutil has the helpers shared by the tests of the cache's servers and clients.
+package testutil
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/internal/cache"
+	"github.com/MojtabaArezoomand/lru_cache/internal/netserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Server is a TCP server of the cache, like the RESP or memcached server.
+type Server interface {
+	Serve(l net.Listener) error
+	Close() error
+}
+
+// NewCache returns a new cache without background cleanup, which is closed when the test finishes.
+func NewCache(t testing.TB, opts ...cache.Option) *cache.Cache {
+	c, err := cache.NewCache(append([]cache.Option{cache.WithCleanupInterval(0)}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+
+	return c
+}
+
+// Serve serves the server on a local port and returns its address, the server is closed when the test finishes.
+func Serve(t testing.TB, srv Server) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() { done <- srv.Serve(l) }()
+
+	t.Cleanup(func() {
+		assert.NoError(t, srv.Close())
+		assert.ErrorIs(t, <-done, netserver.ErrServerClosed)
+	})
+
+	return l.Addr().String()
+}
+
+// ServeHTTP serves the handler on a local port and returns its URL, the server is closed when the test finishes.
+func ServeHTTP(t testing.TB, handler http.Handler) string {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return srv.URL
+}
+
+// Dial connects to the address, the connection is closed when the test finishes.
+// Reads and writes of the connection fail once they take more than 5 seconds.
+func Dial(t testing.TB, addr string) (net.Conn, *bufio.Reader) {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+	return conn, bufio.NewReader(conn)
+}
+
+// ReadN reads n bytes of replies, the test stops if they can't be read.
+func ReadN(t testing.TB, r *bufio.Reader, n int) string {
+	b := make([]byte, n)
+	_, err := io.ReadFull(r, b)
+	require.NoError(t, err)
+
+	return string(b)
+}
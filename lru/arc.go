@@ -0,0 +1,112 @@
+package lru
+
+// Segments of the ARC policy.
+const (
+	// arcT1 holds the resident keys which are used once recently.
+	arcT1 segment = iota
+	// arcT2 holds the resident keys which are used at least twice recently.
+	arcT2
+	// arcB1 holds the ghosts of the keys evicted from T1.
+	arcB1
+	// arcB2 holds the ghosts of the keys evicted from T2.
+	arcB2
+)
+
+// arc is the Adaptive Replacement Cache policy.
+// Evicted keys are remembered in ghost lists, a new key which hits a ghost list shows
+// the list it was evicted from is too small, so the target size of T1 is adapted in its favor.
+// The ghost lists only hold keys, both of them together are at most as large as the capacity.
+type arc[K comparable] struct {
+	segments[K]
+	capacity uint64
+	// target is the adaptive target size of T1.
+	target uint64
+}
+
+// NewARC returns an ARC policy for a cache with the given capacity.
+func NewARC[K comparable](capacity uint64) Policy[K] {
+	return &arc[K]{segments: newSegments[K](4), capacity: capacity}
+}
+
+// Add implements Policy interface.
+// Keys which hit a ghost list adapt the target and are added to T2, other keys are added to T1.
+func (p *arc[K]) Add(key K) {
+	if node, ok := p.nodes[key]; ok {
+		switch node.GetVal() {
+		case arcB1:
+			p.target += ratio(p.size(arcB2), p.size(arcB1))
+			if p.target > p.capacity {
+				p.target = p.capacity
+			}
+		case arcB2:
+			if delta := ratio(p.size(arcB1), p.size(arcB2)); delta < p.target {
+				p.target -= delta
+			} else {
+				p.target = 0
+			}
+		}
+
+		p.move(node, arcT2)
+		return
+	}
+
+	// Forgets the oldest ghosts to keep the ghost lists bounded.
+	if p.size(arcT1)+p.size(arcB1) >= p.capacity && p.size(arcB1) > 0 {
+		p.pop(p.head(arcB1))
+	}
+	if uint64(len(p.nodes)) >= 2*p.capacity {
+		if p.size(arcB2) > 0 {
+			p.pop(p.head(arcB2))
+		} else {
+			p.pop(p.head(arcB1))
+		}
+	}
+
+	p.push(key, arcT1)
+}
+
+// Access implements Policy interface.
+func (p *arc[K]) Access(key K) {
+	if node, ok := p.nodes[key]; ok && (node.GetVal() == arcT1 || node.GetVal() == arcT2) {
+		p.move(node, arcT2)
+	}
+}
+
+// Remove implements Policy interface.
+func (p *arc[K]) Remove(key K) {
+	p.remove(key)
+}
+
+// Evict implements Policy interface.
+// It evicts from T1 if T1 is larger than its target, otherwise from T2, and keeps the key as a ghost.
+func (p *arc[K]) Evict() (K, bool) {
+	t1 := p.size(arcT1)
+
+	node, ghost := p.head(arcT2), arcB2
+	if t1 > 0 && (t1 > p.target || node == nil) {
+		node, ghost = p.head(arcT1), arcB1
+	}
+
+	if node == nil {
+		var zero K
+		return zero, false
+	}
+
+	p.move(node, ghost)
+
+	return node.GetKey(), true
+}
+
+// Keys implements Policy interface.
+// The order is approximate since the victim depends on the target, keys of T1 come first.
+func (p *arc[K]) Keys() []K {
+	return p.keys(arcT1, arcT2)
+}
+
+// ratio returns a divided by b, it's at least one.
+func ratio(a, b uint64) uint64 {
+	if b == 0 || a < b {
+		return 1
+	}
+	return a / b
+}
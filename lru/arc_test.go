@@ -0,0 +1,114 @@
+package lru
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestARC(t *testing.T) {
+	p := NewARC[string](2).(*arc[string])
+
+	_, ok := p.Evict()
+	assert.False(t, ok)
+
+	p.Add("1")
+	p.Add("2")
+	p.Access("1")
+	p.Access("not_found")
+
+	assert.Equal(t, []string{"2"}, p.keys(arcT1))
+	assert.Equal(t, []string{"1"}, p.keys(arcT2))
+
+	// T1 is larger than its target, so it's evicted from and the key becomes a ghost.
+	key, ok := p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "2", key)
+	assert.Equal(t, []string{"2"}, p.keys(arcB1))
+
+	// Ghosts are ignored on access.
+	p.Access("2")
+	assert.Equal(t, []string{"2"}, p.keys(arcB1))
+
+	// A hit in B1 grows the target of T1 and adds the key to T2.
+	p.Add("2")
+	assert.Equal(t, uint64(1), p.target)
+	assert.Equal(t, []string{"1", "2"}, p.Keys())
+	assert.Empty(t, p.keys(arcB1))
+
+	// T1 is empty, so T2 is evicted from.
+	key, ok = p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "1", key)
+	assert.Equal(t, []string{"1"}, p.keys(arcB2))
+
+	p.Add("3")
+	assert.Equal(t, []string{"3", "2"}, p.Keys())
+
+	// T1 isn't larger than its target anymore, so T2 is evicted from.
+	key, ok = p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "2", key)
+	assert.Equal(t, []string{"1", "2"}, p.keys(arcB2))
+
+	// A hit in B2 shrinks the target of T1.
+	p.Add("1")
+	assert.Equal(t, uint64(0), p.target)
+	assert.Equal(t, []string{"3", "1"}, p.Keys())
+
+	p.Remove("3")
+	p.Remove("not_found")
+
+	assert.Equal(t, []string{"1"}, evictAll[string](p))
+}
+
+func TestARCGhostsAreBounded(t *testing.T) {
+	p := NewARC[int](10).(*arc[int])
+
+	for i := 0; i < 1000; i++ {
+		if len(p.Keys()) == 10 {
+			p.Evict()
+		}
+		p.Add(i)
+		if i%2 == 0 {
+			p.Access(i)
+		}
+
+		assert.LessOrEqual(t, len(p.nodes), 20)
+		assert.LessOrEqual(t, p.size(arcT1)+p.size(arcB1), uint64(10))
+		assert.LessOrEqual(t, p.target, uint64(10))
+	}
+}
+
+func TestARCScanResistance(t *testing.T) {
+	cache := newTestCache(t, WithCapacity[string, int](10), WithPolicy[string, int](PolicyARC))
+
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		key := "hot_" + strconv.Itoa(i)
+		assert.NoError(t, cache.Set(ctx, key, i))
+
+		_, err := cache.Get(ctx, key)
+		assert.NoError(t, err)
+	}
+
+	// A one-off scan only evicts keys of T1.
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, cache.Set(ctx, "scan_"+strconv.Itoa(i), i))
+	}
+
+	for i := 0; i < 5; i++ {
+		_, err := cache.Get(ctx, "hot_"+strconv.Itoa(i))
+		assert.NoError(t, err)
+	}
+	assert.Len(t, cache.shards[0].storage, 10)
+}
+
+func TestRatio(t *testing.T) {
+	assert.Equal(t, uint64(1), ratio(0, 0))
+	assert.Equal(t, uint64(1), ratio(1, 2))
+	assert.Equal(t, uint64(3), ratio(7, 2))
+}
@@ -0,0 +1,551 @@
+// Package lru provides a thread-safe, generic cache with per-key expiration.
+// Keys are evicted in LRU order by default, other eviction policies can be plugged in.
+package lru
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type (
+	// Cache is the cache struct, its keys are evicted in the order chosen by its policy.
+	//
+	// Its methods check their context once, before they start. If the context is already done,
+	// they return its error without touching the cache, otherwise they run to completion regardless of the context.
+	// So a write which returns the context's error didn't take effect, and any other result is the write's own.
+	Cache[K comparable, V any] struct {
+		// counters is the first field to keep its uint64s aligned for atomic operations on 32-bit platforms.
+		counters counters
+
+		// shards hold the keys, each of them with its own lock, storage and policy.
+		shards []*shard[K, V]
+		// hasher maps the keys to the shards.
+		hasher    func(key K) uint64
+		numShards uint64
+
+		capacity uint64
+
+		// policyFunc creates the policy of each shard, it's called again to reset the policies on flush.
+		policyFunc func(capacity uint64) (Policy[K], error)
+
+		// maxBytes is the maximum total cost of the entries, zero means unlimited.
+		maxBytes uint64
+		sizer    func(key K, val V) uint64
+
+		defaultTTL time.Duration
+		// refreshAfter is the soft ttl of the keys, stale keys are reloaded in the background by GetOrLoad.
+		refreshAfter    time.Duration
+		negativeTTL     time.Duration
+		cleanupInterval time.Duration
+		onEvict         func(key K, val V, reason EvictionReason)
+
+		// store is the backing store, writes are saved to it by behind if they are written behind.
+		store               Store[K, V]
+		behind              *writeBehind[K, V]
+		writeBehindInterval time.Duration
+		// keys serializes the writes and the loads of each key when there is a store or a log.
+		keys keyLocks[K]
+
+		// log records the writes, logm is held for writing while the log is flushed or its compaction starts.
+		log  *Log[K, V]
+		logm sync.RWMutex
+
+		// loads deduplicates the concurrent loads of GetOrLoad, negatives caches their not found errors.
+		loads     group[K, entry[V]]
+		negatives negatives[K]
+
+		// now returns the current time.
+		now func() time.Time
+
+		stop      chan struct{}
+		closeOnce sync.Once
+	}
+
+	// entry is the value stored in the storage.
+	entry[V any] struct {
+		val       V
+		expiresAt time.Time
+		// staleAt is the time the entry becomes stale, it's zero if the cache doesn't refresh its keys.
+		staleAt time.Time
+		cost    uint64
+		// version changes every time the key is set.
+		version uint64
+	}
+
+	// SetCondition is the condition of a conditional set on whether the key exists.
+	SetCondition int
+
+	// Item is a cached value along with its metadata.
+	Item[V any] struct {
+		Value V
+		// ExpiresAt is the time the key expires, it's zero if the key never expires.
+		ExpiresAt time.Time
+		// Stale is true if the key is older than the refresh interval set by WithRefreshAfter.
+		Stale bool
+		// Version changes every time the key is set, see CompareAndSwap.
+		Version uint64
+	}
+)
+
+// Set conditions.
+const (
+	// SetAlways sets the key whether it exists or not.
+	SetAlways SetCondition = iota
+	// SetIfAbsent only sets the key if it doesn't exist.
+	SetIfAbsent
+	// SetIfPresent only sets the key if it exists.
+	SetIfPresent
+)
+
+// Errors.
+var (
+	ErrNotFound error = errors.New("not found")
+	ErrTooLarge error = errors.New("value is larger than the cache's max bytes")
+
+	// errSkipped is returned by the check of a conditional set when the key mustn't be set.
+	errSkipped = errors.New("skipped")
+)
+
+// New returns a new cache configured by the given options.
+// Close must be called to stop the background cleanup once the cache isn't used anymore.
+func New[K comparable, V any](opts ...Option[K, V]) (*Cache[K, V], error) {
+	cache := Cache[K, V]{
+		numShards:       1,
+		capacity:        DefaultCapacity,
+		cleanupInterval: DefaultCleanupInterval,
+		now:             time.Now,
+		stop:            make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		if err := opt(&cache); err != nil {
+			return nil, err
+		}
+	}
+
+	if cache.maxBytes > 0 && cache.sizer == nil {
+		return nil, errors.New("max bytes requires a sizer")
+	}
+
+	if cache.writeBehindInterval > 0 && cache.store == nil {
+		return nil, errors.New("write behind requires a store")
+	}
+
+	if cache.policyFunc == nil {
+		cache.policyFunc = func(capacity uint64) (Policy[K], error) {
+			return NewLRU[K](), nil
+		}
+	}
+
+	if cache.hasher == nil {
+		if t := reflect.TypeOf((*K)(nil)).Elem(); cache.numShards > 1 && !hashable(t) {
+			return nil, fmt.Errorf("keys of type %s require a hasher to be split across shards", t)
+		}
+		cache.hasher = hashKey[K]
+	}
+
+	if cache.numShards > cache.capacity {
+		return nil, errors.New("shards cannot be more than the capacity")
+	}
+	if cache.maxBytes > 0 && cache.numShards > cache.maxBytes {
+		return nil, errors.New("shards cannot be more than the max bytes")
+	}
+
+	cache.shards = make([]*shard[K, V], cache.numShards)
+	for i := range cache.shards {
+		s, err := newShard(&cache, split(cache.capacity, cache.numShards, i), split(cache.maxBytes, cache.numShards, i))
+		if err != nil {
+			return nil, err
+		}
+		cache.shards[i] = s
+	}
+
+	if cache.log != nil {
+		if err := cache.replayLog(); err != nil {
+			return nil, err
+		}
+		go cache.compactLogs()
+	}
+
+	if cache.cleanupInterval > 0 {
+		go cache.sweep(cache.cleanupInterval)
+	}
+
+	if cache.writeBehindInterval > 0 {
+		cache.behind = newWriteBehind(cache.store)
+		go cache.flushBehind(cache.writeBehindInterval)
+	}
+
+	return &cache, nil
+}
+
+// expired reports whether the entry is expired at the given time.
+func (e entry[V]) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// holds reports whether the condition holds for a key which exists or not.
+func (cond SetCondition) holds(exists bool) bool {
+	switch cond {
+	case SetIfAbsent:
+		return !exists
+	case SetIfPresent:
+		return exists
+	default:
+		return true
+	}
+}
+
+// stale reports whether the entry is stale at the given time.
+func (e entry[V]) stale(now time.Time) bool {
+	return !e.staleAt.IsZero() && !now.Before(e.staleAt)
+}
+
+// Get fetches the key from the cache.
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	item, err := c.GetItem(ctx, key)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	return item.Value, nil
+}
+
+// GetItem fetches the key along with its expiration time and staleness from the cache.
+// If the cache has a store, stale keys are reloaded in the background as by GetOrLoad.
+func (c *Cache[K, V]) GetItem(ctx context.Context, key K) (Item[V], error) {
+	if err := checkContext(ctx); err != nil {
+		return Item[V]{}, err
+	}
+
+	var e entry[V]
+	var err error
+	if c.store != nil {
+		e, err = c.getOrLoad(ctx, key, c.defaultTTL, c.loadStore)
+	} else {
+		e, err = c.get(key)
+	}
+	if err != nil {
+		return Item[V]{}, err
+	}
+
+	return c.item(e), nil
+}
+
+// PeekItem fetches the key along with its expiration time and staleness like GetItem,
+// without counting a hit or a miss, updating the key's recency, or loading it from the store.
+func (c *Cache[K, V]) PeekItem(ctx context.Context, key K) (Item[V], error) {
+	if err := checkContext(ctx); err != nil {
+		return Item[V]{}, err
+	}
+
+	e, ok := c.shard(key).peek(key)
+	if !ok {
+		return Item[V]{}, ErrNotFound
+	}
+
+	return c.item(e), nil
+}
+
+// item returns the item of the entry.
+func (c *Cache[K, V]) item(e entry[V]) Item[V] {
+	item := Item[V]{Value: e.val, ExpiresAt: e.expiresAt, Version: e.version}
+	if c.refreshAfter > 0 {
+		item.Stale = e.stale(c.now())
+	}
+
+	return item
+}
+
+// checkContext returns the context's error if it's already done, it panics if the context is nil.
+func checkContext(ctx context.Context) error {
+	if ctx == nil {
+		panic("Context cannot be nil.")
+	}
+	return ctx.Err()
+}
+
+// get fetches the key's entry from its shard.
+func (c *Cache[K, V]) get(key K) (entry[V], error) {
+	return c.shard(key).get(key)
+}
+
+// Set sets or overwrites the key-value to cache.
+// The key expires after the cache's default ttl, if there is one.
+func (c *Cache[K, V]) Set(ctx context.Context, key K, val V) error {
+	return c.SetWithTTL(ctx, key, val, c.defaultTTL)
+}
+
+// SetWithTTL sets or overwrites the key-value to cache.
+// The key expires after ttl, a zero or negative ttl means the key never expires.
+func (c *Cache[K, V]) SetWithTTL(ctx context.Context, key K, val V, ttl time.Duration) error {
+	_, err := c.SetWithTTLIf(ctx, key, val, ttl, SetAlways)
+	return err
+}
+
+// SetIf sets the key-value to cache like Set, if the condition on whether the key exists holds.
+// It returns false if the condition doesn't hold.
+func (c *Cache[K, V]) SetIf(ctx context.Context, key K, val V, cond SetCondition) (bool, error) {
+	return c.SetWithTTLIf(ctx, key, val, c.defaultTTL, cond)
+}
+
+// SetWithTTLIf sets the key-value to cache like SetWithTTL, if the condition on whether the key exists holds.
+// It returns false if the condition doesn't hold. Expired keys and keys which are only in the store don't exist.
+func (c *Cache[K, V]) SetWithTTLIf(ctx context.Context, key K, val V, ttl time.Duration, cond SetCondition) (bool, error) {
+	var check func(old *entry[V]) error
+	if cond != SetAlways {
+		check = func(old *entry[V]) error {
+			if !cond.holds(old != nil) {
+				return errSkipped
+			}
+			return nil
+		}
+	}
+
+	return c.setChecked(ctx, key, val, ttl, check)
+}
+
+// CompareAndSwap sets the key-value to cache like SetWithTTL, if the key's version is still the given version.
+// It returns false if the key was set since its version was read by GetItem, and ErrNotFound if the key doesn't exist.
+func (c *Cache[K, V]) CompareAndSwap(ctx context.Context, key K, val V, ttl time.Duration, version uint64) (bool, error) {
+	return c.setChecked(ctx, key, val, ttl, func(old *entry[V]) error {
+		if old == nil {
+			return ErrNotFound
+		}
+		if old.version != version {
+			return errSkipped
+		}
+		return nil
+	})
+}
+
+// setChecked sets the key-value to cache if the check of the key's current entry passes, a nil check always passes.
+// The check is called with nil if the key doesn't exist, and returns errSkipped if the key mustn't be set.
+func (c *Cache[K, V]) setChecked(ctx context.Context, key K, val V, ttl time.Duration, check func(old *entry[V]) error) (bool, error) {
+	if err := checkContext(ctx); err != nil {
+		return false, err
+	}
+
+	// The key may have been cached as not found by GetOrLoad.
+	if c.negativeTTL > 0 {
+		c.negatives.delete(key)
+	}
+
+	// The callbacks run after the locks are released, so they may use the key.
+	var evictions []eviction[K, V]
+	defer func() { c.notify(evictions) }()
+
+	// The writes of each key are applied and logged in the same order.
+	if c.store != nil || c.log != nil {
+		defer c.keys.lock(key)()
+	}
+	if c.log != nil {
+		c.logm.RLock()
+		defer c.logm.RUnlock()
+	}
+
+	var err error
+	if c.store != nil {
+		// The key's lock is held, so the key can't be set meanwhile, the check is run before the store is written.
+		var old *entry[V]
+		if e, ok := c.shard(key).peek(key); ok {
+			old = &e
+		}
+		if ok, err := runCheck(check, old); !ok || err != nil {
+			return false, err
+		}
+
+		if evictions, err = c.write(ctx, key, val, ttl); err != nil {
+			return false, err
+		}
+	} else {
+		var ok bool
+		if ok, evictions, err = c.shard(key).setIf(key, c.newEntry(key, val), ttl, check); !ok || err != nil {
+			return false, err
+		}
+	}
+
+	if c.log != nil {
+		return true, c.log.append(c.logSetRecord(key, val, ttl))
+	}
+	return true, nil
+}
+
+// runCheck runs the check of a set, it returns false if the set must be skipped.
+func runCheck[V any](check func(old *entry[V]) error, old *entry[V]) (bool, error) {
+	if check == nil {
+		return true, nil
+	}
+
+	if err := check(old); errors.Is(err, errSkipped) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// set sets or overwrites the key-value in its shard, caller mustn't hold any of the cache's locks.
+func (c *Cache[K, V]) set(key K, val V, ttl time.Duration) error {
+	evictions, err := c.shard(key).set(key, c.newEntry(key, val), ttl)
+	c.notify(evictions)
+	return err
+}
+
+// Expire changes the ttl of the key without changing its value, a zero or negative ttl means the key never expires.
+// It returns ErrNotFound if the key isn't cached or is already expired, keys aren't loaded from the store.
+func (c *Cache[K, V]) Expire(ctx context.Context, key K, ttl time.Duration) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	var evictions []eviction[K, V]
+	defer func() { c.notify(evictions) }()
+
+	if c.log != nil {
+		defer c.keys.lock(key)()
+		c.logm.RLock()
+		defer c.logm.RUnlock()
+	}
+
+	e, evictions, err := c.shard(key).expire(key, ttl)
+	if err != nil || c.log == nil {
+		return err
+	}
+
+	return c.log.append(c.logSetRecord(key, e.val, ttl))
+}
+
+// newEntry returns a new entry of the key-value along with its cost.
+func (c *Cache[K, V]) newEntry(key K, val V) *entry[V] {
+	e := &entry[V]{val: val}
+	if c.sizer != nil {
+		e.cost = c.sizer(key, val)
+	}
+	return e
+}
+
+// Delete removes the key from the cache.
+// It returns ErrNotFound if the key doesn't exist or is already expired.
+func (c *Cache[K, V]) Delete(ctx context.Context, key K) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	var evictions []eviction[K, V]
+	defer func() { c.notify(evictions) }()
+
+	if c.store != nil || c.log != nil {
+		defer c.keys.lock(key)()
+	}
+	if c.log != nil {
+		c.logm.RLock()
+		defer c.logm.RUnlock()
+	}
+
+	var err error
+	if c.store != nil {
+		evictions, err = c.erase(ctx, key)
+	} else {
+		evictions, err = c.shard(key).delete(key)
+	}
+	if err != nil || c.log == nil {
+		return err
+	}
+
+	return c.log.append(logRecord[K, V]{Op: logDelete, Key: key})
+}
+
+// delete removes the key from its shard, caller mustn't hold any of the cache's locks.
+func (c *Cache[K, V]) delete(key K) error {
+	evictions, err := c.shard(key).delete(key)
+	c.notify(evictions)
+	return err
+}
+
+// deleteExpired removes all of the expired keys, one shard at a time.
+func (c *Cache[K, V]) deleteExpired() {
+	for _, s := range c.shards {
+		s.deleteExpired()
+	}
+	c.negatives.deleteExpired(c.now())
+}
+
+// sweep removes expired keys every interval until the cache is closed.
+func (c *Cache[K, V]) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.deleteExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Close stops the background sweeper of expired keys.
+// Expired keys are still removed lazily once they're accessed.
+// The cache's log isn't closed, it must be closed after the cache.
+func (c *Cache[K, V]) Close() {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+	})
+
+	if c.behind != nil {
+		<-c.behind.done
+	}
+}
+
+// Now returns the current time of the cache's clock, which expiration times are relative to.
+func (c *Cache[K, V]) Now() time.Time {
+	return c.now()
+}
+
+// Flush resets the cache.
+func (c *Cache[K, V]) Flush(ctx context.Context) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	if c.log == nil {
+		c.notify(c.flush())
+		return nil
+	}
+
+	// The callbacks run after the log's lock is released, so they may write to the cache.
+	var evictions []eviction[K, V]
+	defer func() { c.notify(evictions) }()
+
+	c.logm.Lock()
+	defer c.logm.Unlock()
+
+	evictions = c.flush()
+	return c.log.append(logRecord[K, V]{Op: logFlush})
+}
+
+// flush resets the cache, one shard at a time, it returns the evictions to be notified by the caller.
+func (c *Cache[K, V]) flush() []eviction[K, V] {
+	var evictions []eviction[K, V]
+	for _, s := range c.shards {
+		evictions = append(evictions, s.flush()...)
+	}
+	c.negatives.flush()
+	return evictions
+}
+
+// shard returns the shard which holds the key.
+func (c *Cache[K, V]) shard(key K) *shard[K, V] {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	return c.shards[c.hasher(key)%uint64(len(c.shards))]
+}
@@ -0,0 +1,714 @@
+package lru
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestCache returns a new cache without background cleanup.
+func newTestCache(t *testing.T, opts ...Option[string, int]) *Cache[string, int] {
+	opts = append([]Option[string, int]{WithCleanupInterval[string, int](0)}, opts...)
+
+	cache, err := New(opts...)
+	assert.NoError(t, err)
+	t.Cleanup(cache.Close)
+
+	return cache
+}
+
+func TestNew(t *testing.T) {
+	cache, err := New[string, int]()
+	assert.NoError(t, err)
+	defer cache.Close()
+
+	assert.NotNil(t, cache.shards[0].storage)
+	assert.IsType(t, &listPolicy[string]{}, cache.shards[0].policy)
+	assert.EqualValues(t, 2048, cache.capacity)
+	assert.Equal(t, time.Minute, cache.cleanupInterval)
+	assert.Zero(t, cache.defaultTTL)
+	assert.Nil(t, cache.onEvict)
+	assert.NotNil(t, cache.now)
+}
+
+func TestGetSet(t *testing.T) {
+	cache := newTestCache(t, WithCapacity[string, int](3))
+
+	cache.set("first", 1, 0)
+
+	assert.EqualValues(t, 1, len(cache.shards[0].storage))
+
+	res, err := cache.get("first")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, res.val)
+
+	// Overwriting the first key
+	cache.set("first", 2, 0)
+
+	assert.EqualValues(t, 1, len(cache.shards[0].storage))
+
+	res, err = cache.get("first")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, res.val)
+
+	cache.set("second", 4, 0)
+	cache.set("third", 5, 0)
+
+	assert.EqualValues(t, 3, len(cache.shards[0].storage))
+
+	assert.Equal(t, []string{"first", "second", "third"}, cache.shards[0].policy.Keys())
+
+	// Exceeding the capacity
+	cache.set("fourth", 10, 0)
+
+	assert.EqualValues(t, 3, len(cache.shards[0].storage))
+
+	assert.Equal(t, []string{"second", "third", "fourth"}, cache.shards[0].policy.Keys())
+
+	_, err = cache.get("first")
+	assert.ErrorIs(t, ErrNotFound, err)
+
+	_, err = cache.get("third")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"second", "fourth", "third"}, cache.shards[0].policy.Keys())
+}
+
+func TestGetSetDataRace(t *testing.T) {
+	cache := newTestCache(t)
+
+	cache.set("first", 1, 0)
+
+	var wg sync.WaitGroup
+	wg.Add(100)
+
+	for i := 0; i < 100; i++ {
+		go func() {
+			defer wg.Done()
+
+			_, err := cache.get("first")
+			assert.NoError(t, err)
+			cache.set("first", 1, 0)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestTestGetSetContext(t *testing.T) {
+	cache := newTestCache(t)
+
+	ctx1, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := cache.Set(ctx1, "1", 1)
+
+	assert.ErrorIs(t, context.Canceled, err)
+
+	_, err = cache.Get(ctx1, "1")
+
+	assert.ErrorIs(t, context.Canceled, err)
+
+	// The cancelled write didn't take effect.
+	assert.Empty(t, cache.shards[0].storage)
+	assert.Equal(t, Stats{}, cache.Stats())
+
+	ctx2, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err = cache.Set(ctx2, "1", 1)
+
+	assert.NoError(t, err)
+
+	v, err := cache.Get(ctx2, "1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+}
+
+func TestGetSetPanics(t *testing.T) {
+	cache := newTestCache(t)
+
+	assert.Panics(t, func() {
+		cache.Set(nil, "", 1)
+	})
+
+	assert.Panics(t, func() {
+		cache.Get(nil, "")
+	})
+}
+
+func TestFlush(t *testing.T) {
+	cache := newTestCache(t)
+
+	cache.set("first_key", 1, 0)
+
+	cache.flush()
+
+	_, err := cache.get("first_key")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Empty(t, cache.shards[0].policy.Keys())
+	assert.Empty(t, cache.shards[0].storage)
+}
+
+func TestFlushContext(t *testing.T) {
+	cache := newTestCache(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := cache.Flush(ctx)
+	assert.NoError(t, err)
+
+	cancel()
+
+	cache.set("key", 1, 0)
+
+	err = cache.Flush(ctx)
+	assert.ErrorIs(t, err, ctx.Err())
+
+	// The cancelled flush didn't take effect.
+	assert.Contains(t, cache.shards[0].storage, "key")
+
+	assert.Panics(t, func() {
+		cache.Flush(nil)
+	})
+}
+
+// fakeClock is a manually advanced clock for testing expiration.
+// It's safe to read the clock in the background while the test advances it.
+type fakeClock struct {
+	m   sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func TestSetWithTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t, WithClock[string, int](clock.Now))
+
+	cache.set("expiring", 1, time.Second)
+	cache.set("forever", 2, 0)
+	cache.set("negative", 3, -time.Second)
+
+	v, err := cache.get("expiring")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v.val)
+
+	clock.Advance(999 * time.Millisecond)
+
+	_, err = cache.get("expiring")
+	assert.NoError(t, err)
+
+	clock.Advance(time.Millisecond)
+
+	_, err = cache.get("expiring")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Expired key is reclaimed lazily by get.
+	assert.NotContains(t, cache.shards[0].storage, "expiring")
+	assert.Len(t, cache.shards[0].storage, 2)
+
+	clock.Advance(time.Hour)
+
+	v, err = cache.get("forever")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v.val)
+
+	v, err = cache.get("negative")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, v.val)
+
+	// Overwriting resets the ttl.
+	cache.set("forever", 4, time.Second)
+	clock.Advance(time.Second)
+
+	_, err = cache.get("forever")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	cache.set("forever", 5, time.Second)
+	cache.set("forever", 6, 0)
+	clock.Advance(time.Second)
+
+	v, err = cache.get("forever")
+	assert.NoError(t, err)
+	assert.Equal(t, 6, v.val)
+}
+
+func TestSetWithTTLContext(t *testing.T) {
+	cache := newTestCache(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	assert.NoError(t, cache.SetWithTTL(ctx, "key", 1, time.Minute))
+
+	v, err := cache.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	cancel()
+
+	assert.ErrorIs(t, cache.SetWithTTL(ctx, "key", 1, time.Minute), context.Canceled)
+
+	assert.Panics(t, func() {
+		cache.SetWithTTL(nil, "key", 1, time.Minute)
+	})
+}
+
+func TestDeleteExpired(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t, WithClock[string, int](clock.Now))
+
+	cache.set("first", 1, time.Second)
+	cache.set("second", 2, 2*time.Second)
+	cache.set("third", 3, 0)
+
+	clock.Advance(time.Second)
+	cache.deleteExpired()
+
+	assert.Equal(t, []string{"second", "third"}, cache.shards[0].policy.Keys())
+	assert.NotContains(t, cache.shards[0].storage, "first")
+
+	clock.Advance(time.Second)
+	cache.deleteExpired()
+
+	assert.Equal(t, []string{"third"}, cache.shards[0].policy.Keys())
+	assert.Len(t, cache.shards[0].storage, 1)
+}
+
+func TestSweep(t *testing.T) {
+	cache, err := New(WithCleanupInterval[string, int](time.Millisecond))
+	assert.NoError(t, err)
+	defer cache.Close()
+
+	cache.set("key", 1, time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		cache.shards[0].m.Lock()
+		defer cache.shards[0].m.Unlock()
+
+		return len(cache.shards[0].storage) == 0
+	}, time.Second, time.Millisecond)
+
+	cache.Close()
+
+	// Closing twice is a no-op.
+	assert.NotPanics(t, cache.Close)
+}
+
+func TestGetItem(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t, WithClock[string, int](clock.Now))
+
+	ctx := context.Background()
+
+	assert.NoError(t, cache.SetWithTTL(ctx, "expiring", 1, time.Minute))
+	assert.NoError(t, cache.Set(ctx, "forever", 2))
+
+	item, err := cache.GetItem(ctx, "expiring")
+	assert.NoError(t, err)
+	assert.Equal(t, Item[int]{Value: 1, ExpiresAt: time.Unix(1060, 0), Version: 1}, item)
+
+	item, err = cache.GetItem(ctx, "forever")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, item.Value)
+	assert.True(t, item.ExpiresAt.IsZero())
+	assert.Equal(t, uint64(2), item.Version)
+
+	// The version changes every time the key is set.
+	assert.NoError(t, cache.Set(ctx, "forever", 2))
+
+	item, err = cache.GetItem(ctx, "forever")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), item.Version)
+
+	_, err = cache.GetItem(ctx, "not_found")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = cache.GetItem(canceled, "forever")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestPeekItem(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	store := NewMemoryStore[string, int]()
+	cache := newTestCache(t, WithCapacity[string, int](2), WithStore[string, int](store), WithClock[string, int](clock.Now))
+
+	ctx := context.Background()
+
+	assert.NoError(t, cache.SetWithTTL(ctx, "first", 1, time.Minute))
+	assert.NoError(t, cache.Set(ctx, "second", 2))
+	assert.NoError(t, store.Save(ctx, "stored", 3))
+
+	item, err := cache.PeekItem(ctx, "first")
+	assert.NoError(t, err)
+	assert.Equal(t, Item[int]{Value: 1, ExpiresAt: time.Unix(1060, 0), Version: 1}, item)
+
+	// Keys which are only in the store aren't loaded.
+	_, err = cache.PeekItem(ctx, "stored")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NotContains(t, cache.shards[0].storage, "stored")
+
+	// Peeks don't count as hits or misses, nor make the keys recently used.
+	assert.Zero(t, cache.Stats().Hits)
+	assert.Zero(t, cache.Stats().Misses)
+
+	assert.NoError(t, cache.SetWithTTL(ctx, "third", 3, time.Second))
+
+	_, err = cache.PeekItem(ctx, "first")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Expired keys don't exist.
+	clock.Advance(time.Second)
+
+	_, err = cache.PeekItem(ctx, "third")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = cache.PeekItem(canceled, "second")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDelete(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t, WithClock[string, int](clock.Now))
+
+	cache.set("first", 1, 0)
+	cache.set("second", 2, 0)
+	cache.set("third", 3, time.Second)
+
+	assert.NoError(t, cache.delete("second"))
+
+	assert.Len(t, cache.shards[0].storage, 2)
+	assert.NotContains(t, cache.shards[0].storage, "second")
+	assert.Equal(t, []string{"first", "third"}, cache.shards[0].policy.Keys())
+
+	_, err := cache.get("second")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.ErrorIs(t, cache.delete("second"), ErrNotFound)
+
+	// Deleting an expired key reclaims it but reports it as not found.
+	clock.Advance(time.Second)
+
+	assert.ErrorIs(t, cache.delete("third"), ErrNotFound)
+	assert.Equal(t, []string{"first"}, cache.shards[0].policy.Keys())
+	assert.NotContains(t, cache.shards[0].storage, "third")
+
+	assert.NoError(t, cache.delete("first"))
+	assert.Empty(t, cache.shards[0].policy.Keys())
+	assert.Empty(t, cache.shards[0].storage)
+}
+
+func TestDeleteContext(t *testing.T) {
+	cache := newTestCache(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cache.set("key", 1, 0)
+
+	assert.NoError(t, cache.Delete(ctx, "key"))
+	assert.ErrorIs(t, cache.Delete(ctx, "key"), ErrNotFound)
+
+	cancel()
+
+	assert.ErrorIs(t, cache.Delete(ctx, "key"), context.Canceled)
+
+	assert.Panics(t, func() {
+		cache.Delete(nil, "key")
+	})
+}
+
+func TestGenericTypes(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	cache, err := New(WithCapacity[int, *user](2), WithCleanupInterval[int, *user](0))
+	assert.NoError(t, err)
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	assert.NoError(t, cache.Set(ctx, 1, &user{Name: "first"}))
+	assert.NoError(t, cache.Set(ctx, 2, &user{Name: "second"}))
+
+	u, err := cache.Get(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", u.Name)
+
+	assert.NoError(t, cache.Set(ctx, 3, &user{Name: "third"}))
+
+	u, err = cache.Get(ctx, 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, u)
+}
+
+func TestMaxBytes(t *testing.T) {
+	var evicted []string
+
+	cache := newTestCache(t,
+		WithMaxBytes[string, int](10),
+		WithSizer(func(key string, val int) uint64 { return uint64(val) }),
+		WithOnEvict(func(key string, val int, reason EvictionReason) {
+			if reason == EvictionReasonCapacity {
+				evicted = append(evicted, key)
+			}
+		}),
+	)
+
+	assert.NoError(t, cache.set("first", 3, 0))
+	assert.NoError(t, cache.set("second", 3, 0))
+	assert.NoError(t, cache.set("third", 3, 0))
+
+	assert.EqualValues(t, 9, cache.shards[0].bytes)
+	assert.Empty(t, evicted)
+
+	// Evicts from the head until the new key fits.
+	assert.NoError(t, cache.set("fourth", 5, 0))
+
+	assert.EqualValues(t, 8, cache.shards[0].bytes)
+	assert.Equal(t, []string{"first", "second"}, evicted)
+	assert.Equal(t, []string{"third", "fourth"}, cache.shards[0].policy.Keys())
+
+	// Overwriting accounts for the old cost.
+	assert.NoError(t, cache.set("fourth", 7, 0))
+
+	assert.EqualValues(t, 10, cache.shards[0].bytes)
+	assert.Len(t, cache.shards[0].storage, 2)
+
+	// Overwriting never evicts the overwritten key itself.
+	assert.NoError(t, cache.set("fourth", 10, 0))
+
+	assert.EqualValues(t, 10, cache.shards[0].bytes)
+	assert.Equal(t, []string{"first", "second", "third"}, evicted)
+	assert.Equal(t, []string{"fourth"}, cache.shards[0].policy.Keys())
+
+	assert.ErrorIs(t, cache.set("huge", 11, 0), ErrTooLarge)
+	assert.ErrorIs(t, cache.SetWithTTL(context.Background(), "fourth", 11, 0), ErrTooLarge)
+	assert.NotContains(t, cache.shards[0].storage, "huge")
+	assert.Equal(t, 10, cache.shards[0].storage["fourth"].val)
+
+	assert.NoError(t, cache.delete("fourth"))
+	assert.Zero(t, cache.shards[0].bytes)
+
+	assert.NoError(t, cache.set("first", 1, 0))
+	cache.flush()
+	assert.Zero(t, cache.shards[0].bytes)
+}
+
+func TestMaxBytesWithCapacity(t *testing.T) {
+	cache := newTestCache(t,
+		WithCapacity[string, int](2),
+		WithMaxBytes[string, int](100),
+		WithSizer(func(key string, val int) uint64 { return uint64(val) }),
+	)
+
+	assert.NoError(t, cache.set("first", 1, 0))
+	assert.NoError(t, cache.set("second", 1, 0))
+	assert.NoError(t, cache.set("third", 1, 0))
+
+	assert.Len(t, cache.shards[0].storage, 2)
+	assert.EqualValues(t, 2, cache.shards[0].bytes)
+	assert.NotContains(t, cache.shards[0].storage, "first")
+}
+
+func BenchmarkCache(b *testing.B) {
+	cache, err := New(WithCapacity[string, int](1024), WithCleanupInterval[string, int](0))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	keys := make([]string, 2048)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+
+	b.Run("get", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = cache.Get(ctx, keys[i%len(keys)])
+		}
+	})
+
+	b.Run("set", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = cache.Set(ctx, keys[i%len(keys)], i)
+		}
+	})
+
+	b.Run("delete", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = cache.Delete(ctx, keys[i%len(keys)])
+		}
+	})
+
+	b.Run("flush", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = cache.Flush(ctx)
+		}
+	})
+}
+
+func TestSetIf(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	store := NewMemoryStore[string, int]()
+
+	for name, opts := range map[string][]Option[string, int]{
+		"cache": nil,
+		"store": {WithStore[string, int](store)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cache := newTestCache(t, append(opts, WithClock[string, int](clock.Now))...)
+
+			ctx := context.Background()
+
+			ok, err := cache.SetIf(ctx, "key", 1, SetIfPresent)
+			assert.NoError(t, err)
+			assert.False(t, ok)
+
+			ok, err = cache.SetIf(ctx, "key", 1, SetIfAbsent)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = cache.SetIf(ctx, "key", 2, SetIfAbsent)
+			assert.NoError(t, err)
+			assert.False(t, ok)
+
+			ok, err = cache.SetWithTTLIf(ctx, "key", 3, time.Second, SetIfPresent)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+
+			val, err := cache.Get(ctx, "key")
+			assert.NoError(t, err)
+			assert.Equal(t, 3, val)
+
+			// Expired keys don't exist.
+			clock.Advance(time.Second)
+
+			ok, err = cache.SetIf(ctx, "key", 4, SetIfAbsent)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = cache.SetIf(ctx, "key", 5, SetAlways)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+
+			cancelled, cancel := context.WithCancel(ctx)
+			cancel()
+
+			_, err = cache.SetIf(cancelled, "key", 6, SetAlways)
+			assert.ErrorIs(t, err, context.Canceled)
+
+			val, err = cache.Get(ctx, "key")
+			assert.NoError(t, err)
+			assert.Equal(t, 5, val)
+		})
+	}
+
+	val, err := store.Load(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, val)
+}
+
+func TestExpire(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t, WithClock[string, int](clock.Now))
+
+	ctx := context.Background()
+
+	assert.ErrorIs(t, cache.Expire(ctx, "key", time.Second), ErrNotFound)
+
+	assert.NoError(t, cache.Set(ctx, "key", 1))
+	assert.NoError(t, cache.Expire(ctx, "key", time.Minute))
+
+	item, err := cache.GetItem(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, item.Value)
+	assert.Equal(t, clock.now.Add(time.Minute), item.ExpiresAt)
+
+	assert.NoError(t, cache.Expire(ctx, "key", 0))
+
+	item, err = cache.GetItem(ctx, "key")
+	assert.NoError(t, err)
+	assert.True(t, item.ExpiresAt.IsZero())
+
+	assert.NoError(t, cache.Expire(ctx, "key", time.Second))
+	clock.Advance(time.Second)
+
+	assert.ErrorIs(t, cache.Expire(ctx, "key", time.Second), ErrNotFound)
+	assert.Zero(t, cache.Stats().Size)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	assert.ErrorIs(t, cache.Expire(cancelled, "key", time.Second), context.Canceled)
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	store := NewMemoryStore[string, int]()
+
+	for name, opts := range map[string][]Option[string, int]{
+		"cache": nil,
+		"store": {WithStore[string, int](store)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cache := newTestCache(t, opts...)
+
+			ctx := context.Background()
+
+			_, err := cache.CompareAndSwap(ctx, "key", 1, 0, 0)
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			assert.NoError(t, cache.Set(ctx, "key", 1))
+
+			item, err := cache.GetItem(ctx, "key")
+			assert.NoError(t, err)
+
+			ok, err := cache.CompareAndSwap(ctx, "key", 2, time.Minute, item.Version)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+
+			// The key was set since its version was read.
+			ok, err = cache.CompareAndSwap(ctx, "key", 3, 0, item.Version)
+			assert.NoError(t, err)
+			assert.False(t, ok)
+
+			item, err = cache.GetItem(ctx, "key")
+			assert.NoError(t, err)
+			assert.Equal(t, 2, item.Value)
+			assert.False(t, item.ExpiresAt.IsZero())
+		})
+	}
+
+	val, err := store.Load(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, val)
+}
@@ -0,0 +1,147 @@
+package lru
+
+import (
+	"sync/atomic"
+
+	linkedlist "github.com/MojtabaArezoomand/lru_cache/internal/linked_list"
+)
+
+// clock is an approximate LRU policy which only sets a visited bit on access,
+// so hits are recorded under the cache's read lock and reads aren't serialized.
+// Keys are kept in insertion order, the front of the list is the oldest key.
+type clock[K comparable] struct {
+	// The nodes' values are the visited bits, which are set atomically.
+	list  *linkedlist.DoublyLinkedList[K, *uint32]
+	nodes map[K]*linkedlist.Node[K, *uint32]
+
+	// sieve keeps the visited keys in place and the hand where it stopped, instead of
+	// moving the visited keys to the back and always sweeping from the front.
+	sieve bool
+	hand  *linkedlist.Node[K, *uint32]
+}
+
+// NewCLOCK returns a CLOCK policy, it gives the visited keys a second chance by moving them
+// to the back while the hand sweeps the keys from the oldest one.
+func NewCLOCK[K comparable]() Policy[K] {
+	return newClock[K](false)
+}
+
+// NewSIEVE returns a SIEVE policy, its hand sweeps the keys from the oldest one towards the newest one
+// clearing the visited bits, evicts the first key which isn't visited and resumes from there on the next eviction.
+func NewSIEVE[K comparable]() Policy[K] {
+	return newClock[K](true)
+}
+
+// newClock returns a new clock policy.
+func newClock[K comparable](sieve bool) *clock[K] {
+	return &clock[K]{
+		list:  linkedlist.NewDoublyLinkedList[K, *uint32](),
+		nodes: make(map[K]*linkedlist.Node[K, *uint32]),
+		sieve: sieve,
+	}
+}
+
+// Add implements Policy interface.
+func (p *clock[K]) Add(key K) {
+	p.nodes[key] = p.list.AddToBack(key, new(uint32))
+}
+
+// Access implements Policy interface.
+func (p *clock[K]) Access(key K) {
+	p.AccessShared(key)
+}
+
+// AccessShared implements SharedPolicy interface.
+func (p *clock[K]) AccessShared(key K) {
+	node, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+
+	// Avoids writing to the shared cache line if the bit is already set.
+	if visited := node.GetVal(); atomic.LoadUint32(visited) == 0 {
+		atomic.StoreUint32(visited, 1)
+	}
+}
+
+// Remove implements Policy interface.
+func (p *clock[K]) Remove(key K) {
+	node, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+
+	if node == p.hand {
+		p.hand = node.Next()
+	}
+
+	p.list.Remove(node)
+	delete(p.nodes, key)
+}
+
+// Evict implements Policy interface.
+// The hand goes around at most once before finding a key which isn't visited, since it clears the bits it passes.
+func (p *clock[K]) Evict() (K, bool) {
+	node := p.hand
+	if node == nil {
+		node = p.list.Head()
+	}
+
+	for node != nil {
+		visited := node.GetVal()
+		if atomic.LoadUint32(visited) == 0 {
+			break
+		}
+		atomic.StoreUint32(visited, 0)
+
+		if p.sieve {
+			node = p.next(node)
+		} else {
+			p.list.MoveToBack(node)
+			node = p.list.Head()
+		}
+	}
+
+	if node == nil {
+		var zero K
+		return zero, false
+	}
+
+	if p.sieve {
+		// A nil hand starts from the front again.
+		p.hand = node.Next()
+	}
+
+	key := node.GetKey()
+	p.Remove(key)
+
+	return key, true
+}
+
+// Keys implements Policy interface.
+// The order is approximate since visited keys are skipped, the keys are returned starting from the hand.
+func (p *clock[K]) Keys() []K {
+	keys := make([]K, 0, len(p.nodes))
+
+	start := p.hand
+	if start == nil {
+		start = p.list.Head()
+	}
+
+	for node := start; node != nil; node = node.Next() {
+		keys = append(keys, node.GetKey())
+	}
+	for node := p.list.Head(); node != start; node = node.Next() {
+		keys = append(keys, node.GetKey())
+	}
+
+	return keys
+}
+
+// next returns the node after the given one, wrapping around to the front of the list.
+func (p *clock[K]) next(node *linkedlist.Node[K, *uint32]) *linkedlist.Node[K, *uint32] {
+	if next := node.Next(); next != nil {
+		return next
+	}
+	return p.list.Head()
+}
@@ -0,0 +1,207 @@
+package lru
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCLOCK(t *testing.T) {
+	p := NewCLOCK[string]()
+
+	_, ok := p.Evict()
+	assert.False(t, ok)
+
+	p.Add("1")
+	p.Add("2")
+	p.Add("3")
+	p.Add("4")
+
+	p.Access("1")
+	p.Access("3")
+	p.Access("not_found")
+
+	// Visited keys are given a second chance at the back.
+	key, ok := p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "2", key)
+	assert.Equal(t, []string{"3", "4", "1"}, p.Keys())
+
+	key, ok = p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "4", key)
+	assert.Equal(t, []string{"1", "3"}, p.Keys())
+
+	// If every key is visited, the hand goes around once.
+	p.Access("1")
+	p.Access("3")
+
+	key, ok = p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "1", key)
+
+	p.Remove("3")
+	p.Remove("not_found")
+
+	_, ok = p.Evict()
+	assert.False(t, ok)
+}
+
+func TestSIEVE(t *testing.T) {
+	p := NewSIEVE[string]().(*clock[string])
+
+	_, ok := p.Evict()
+	assert.False(t, ok)
+
+	p.Add("1")
+	p.Add("2")
+	p.Add("3")
+	p.Add("4")
+
+	p.Access("1")
+	p.Access("3")
+
+	// Visited keys stay in place and the hand stops after the evicted key.
+	key, ok := p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "2", key)
+	assert.Equal(t, []string{"3", "4", "1"}, p.Keys())
+
+	key, ok = p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "4", key)
+	assert.Nil(t, p.hand)
+	assert.Equal(t, []string{"1", "3"}, p.Keys())
+
+	// New keys are added to the back, the hand starts from the front again.
+	p.Add("5")
+	p.Access("5")
+
+	key, ok = p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "1", key)
+	assert.Equal(t, []string{"3", "5"}, p.Keys())
+
+	// Removing the key under the hand moves the hand forward.
+	p.Remove("3")
+	p.Remove("not_found")
+	assert.Equal(t, []string{"5"}, p.Keys())
+
+	// The hand wraps around.
+	key, ok = p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "5", key)
+
+	_, ok = p.Evict()
+	assert.False(t, ok)
+}
+
+func TestSharedGet(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	cache := newTestCache(t, WithCapacity[string, int](2), WithPolicy[string, int](PolicySIEVE), WithClock[string, int](clock.Now))
+	assert.True(t, cache.shards[0].shared)
+
+	ctx := context.Background()
+
+	assert.NoError(t, cache.Set(ctx, "first", 1))
+	assert.NoError(t, cache.SetWithTTL(ctx, "second", 2, time.Minute))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			val, err := cache.Get(ctx, "first")
+			assert.NoError(t, err)
+			assert.Equal(t, 1, val)
+
+			_, err = cache.Get(ctx, "not_found")
+			assert.ErrorIs(t, err, ErrNotFound)
+		}()
+	}
+	wg.Wait()
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(10), stats.Hits)
+	assert.Equal(t, uint64(10), stats.Misses)
+
+	// The visited key survives the eviction.
+	assert.NoError(t, cache.Set(ctx, "third", 3))
+
+	_, err := cache.Get(ctx, "second")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Expired keys are removed under the write lock.
+	assert.NoError(t, cache.SetWithTTL(ctx, "fourth", 4, time.Minute))
+	clock.Advance(time.Hour)
+
+	_, err = cache.Get(ctx, "fourth")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NotContains(t, cache.shards[0].storage, "fourth")
+	assert.Equal(t, uint64(1), cache.Stats().Expirations)
+
+	// Other policies are never shared.
+	assert.False(t, newTestCache(t).shards[0].shared)
+}
+
+func TestSharedGetFlush(t *testing.T) {
+	cache := newTestCache(t, WithPolicy[string, int](PolicyCLOCK))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				cache.Get(ctx, "key")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.NoError(t, cache.Set(ctx, "key", j))
+				assert.NoError(t, cache.Flush(ctx))
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.True(t, cache.shards[0].shared)
+}
+
+func BenchmarkGetParallel(b *testing.B) {
+	for _, policy := range []PolicyName{PolicyLRU, PolicyCLOCK, PolicySIEVE} {
+		b.Run(string(policy), func(b *testing.B) {
+			cache, err := New(WithCapacity[string, int](1024), WithPolicy[string, int](policy), WithCleanupInterval[string, int](0))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer cache.Close()
+
+			ctx := context.Background()
+
+			keys := make([]string, 1024)
+			for i := range keys {
+				keys[i] = strconv.Itoa(i)
+				if err := cache.Set(ctx, keys[i], i); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					_, _ = cache.Get(ctx, keys[i%len(keys)])
+					i++
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package lru
+
+// EvictionReason is the reason a key is removed from the cache.
+type EvictionReason int
+
+// Eviction reasons.
+const (
+	// EvictionReasonCapacity means the key was evicted to respect the capacity or max bytes.
+	EvictionReasonCapacity EvictionReason = iota + 1
+	// EvictionReasonExpired means the key's ttl has passed.
+	EvictionReasonExpired
+	// EvictionReasonDeleted means the key was deleted by Delete.
+	EvictionReasonDeleted
+	// EvictionReasonReplaced means the key's value was overwritten by a new one.
+	EvictionReasonReplaced
+	// EvictionReasonFlushed means the key was removed by Flush.
+	EvictionReasonFlushed
+)
+
+// String implements fmt.Stringer interface.
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictionReasonCapacity:
+		return "capacity"
+	case EvictionReasonExpired:
+		return "expired"
+	case EvictionReasonDeleted:
+		return "deleted"
+	case EvictionReasonReplaced:
+		return "replaced"
+	case EvictionReasonFlushed:
+		return "flushed"
+	default:
+		return "unknown"
+	}
+}
+
+// eviction is a removed key-value which is waiting to be passed to the eviction callback.
+type eviction[K comparable, V any] struct {
+	key    K
+	val    V
+	reason EvictionReason
+}
+
+// evicted records a removed key-value for the eviction callback.
+// It's a no-op if there is no eviction callback. Caller must hold the lock.
+func (c *Cache[K, V]) evicted(evictions []eviction[K, V], key K, val V, reason EvictionReason) []eviction[K, V] {
+	if c.onEvict == nil {
+		return evictions
+	}
+
+	return append(evictions, eviction[K, V]{key: key, val: val, reason: reason})
+}
+
+// notify calls the eviction callback for the evictions.
+// It must be called after the lock is released, so the callback may use the cache.
+func (c *Cache[K, V]) notify(evictions []eviction[K, V]) {
+	for _, e := range evictions {
+		c.onEvict(e.key, e.val, e.reason)
+	}
+}
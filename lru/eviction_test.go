@@ -0,0 +1,132 @@
+package lru
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type evictionRecord struct {
+	key    string
+	val    int
+	reason EvictionReason
+}
+
+func TestEvictionReasonString(t *testing.T) {
+	assert.Equal(t, "capacity", EvictionReasonCapacity.String())
+	assert.Equal(t, "expired", EvictionReasonExpired.String())
+	assert.Equal(t, "deleted", EvictionReasonDeleted.String())
+	assert.Equal(t, "replaced", EvictionReasonReplaced.String())
+	assert.Equal(t, "flushed", EvictionReasonFlushed.String())
+	assert.Equal(t, "unknown", EvictionReason(0).String())
+}
+
+func TestEvictionReasons(t *testing.T) {
+	var records []evictionRecord
+
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t,
+		WithCapacity[string, int](2),
+		WithClock[string, int](clock.Now),
+		WithOnEvict(func(key string, val int, reason EvictionReason) {
+			records = append(records, evictionRecord{key: key, val: val, reason: reason})
+		}),
+	)
+
+	// Replaced
+	cache.set("first", 1, 0)
+	cache.set("first", 2, 0)
+
+	// Capacity
+	cache.set("second", 3, 0)
+	cache.set("third", 4, 0)
+
+	// Deleted
+	assert.NoError(t, cache.delete("second"))
+
+	// Expired by get
+	cache.set("expiring", 5, time.Second)
+	clock.Advance(time.Second)
+	_, err := cache.get("expiring")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Expired by delete
+	cache.set("expiring", 6, time.Second)
+	clock.Advance(time.Second)
+	assert.ErrorIs(t, cache.delete("expiring"), ErrNotFound)
+
+	// Expired by sweeper
+	cache.set("expiring", 7, time.Second)
+	clock.Advance(time.Second)
+	cache.deleteExpired()
+
+	// Expired by overwrite
+	cache.set("expiring", 8, time.Second)
+	clock.Advance(time.Second)
+	cache.set("expiring", 9, 0)
+
+	// Flushed, in LRU order
+	assert.NoError(t, cache.Flush(context.Background()))
+
+	assert.Equal(t, []evictionRecord{
+		{key: "first", val: 1, reason: EvictionReasonReplaced},
+		{key: "first", val: 2, reason: EvictionReasonCapacity},
+		{key: "second", val: 3, reason: EvictionReasonDeleted},
+		{key: "expiring", val: 5, reason: EvictionReasonExpired},
+		{key: "expiring", val: 6, reason: EvictionReasonExpired},
+		{key: "expiring", val: 7, reason: EvictionReasonExpired},
+		{key: "expiring", val: 8, reason: EvictionReasonExpired},
+		{key: "third", val: 4, reason: EvictionReasonFlushed},
+		{key: "expiring", val: 9, reason: EvictionReasonFlushed},
+	}, records)
+}
+
+func TestOnEvictOutsideLock(t *testing.T) {
+	var cache *Cache[string, int]
+
+	calls := 0
+	cache = newTestCache(t, WithOnEvict(func(key string, val int, reason EvictionReason) {
+		calls++
+
+		// Using the cache would deadlock if the lock was still held.
+		assert.NoError(t, cache.Set(context.Background(), "evicted_"+key, val))
+	}))
+
+	ctx := context.Background()
+
+	assert.NoError(t, cache.Set(ctx, "key", 1))
+	assert.NoError(t, cache.Delete(ctx, "key"))
+
+	v, err := cache.Get(ctx, "evicted_key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	assert.NoError(t, cache.Flush(ctx))
+	assert.Equal(t, 2, calls)
+}
+
+func TestOnEvictOutsideKeyLock(t *testing.T) {
+	store := NewMemoryStore[string, int]()
+	var cache *Cache[string, int]
+	cache = newTestCache(t, WithStore[string, int](store), WithOnEvict(func(key string, val int, reason EvictionReason) {
+		// Writing the key would deadlock if its lock was still held.
+		if reason == EvictionReasonDeleted {
+			assert.NoError(t, cache.Set(context.Background(), key, val+1))
+		}
+	}))
+
+	ctx := context.Background()
+
+	assert.NoError(t, cache.Set(ctx, "key", 1))
+	assert.NoError(t, cache.Delete(ctx, "key"))
+
+	v, err := cache.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v)
+
+	v, err = store.Load(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v)
+}
@@ -0,0 +1,95 @@
+package lru
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileStore is a Store which keeps each value JSON encoded in its own file of a directory.
+// Files are named after the SHA-256 hash of their keys, so any key is a valid file name.
+type FileStore[V any] struct {
+	dir string
+}
+
+// NewFileStore returns a new file store in the directory, the directory is created if it doesn't exist.
+func NewFileStore[V any](dir string) (*FileStore[V], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileStore[V]{dir: dir}, nil
+}
+
+// Load implements Store interface.
+func (s *FileStore[V]) Load(ctx context.Context, key string) (V, error) {
+	var val V
+
+	if err := ctx.Err(); err != nil {
+		return val, err
+	}
+
+	b, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return val, ErrNotFound
+	}
+	if err != nil {
+		return val, err
+	}
+
+	err = json.Unmarshal(b, &val)
+	return val, err
+}
+
+// Save implements Store interface.
+// The value is written to a temporary file which replaces the key's file, so a failed save never leaves a partial value.
+func (s *FileStore[V]) Save(ctx context.Context, key string, val V) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path(key))
+}
+
+// Delete implements Store interface.
+func (s *FileStore[V]) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// path returns the path of the key's file.
+func (s *FileStore[V]) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
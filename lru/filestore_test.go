@@ -0,0 +1,80 @@
+package lru
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "store")
+
+	store, err := NewFileStore[map[string]int](dir)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = store.Load(ctx, "key")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, store.Save(ctx, "key", map[string]int{"a": 1}))
+	assert.NoError(t, store.Save(ctx, "../key/with/slashes", map[string]int{"b": 2}))
+
+	val, err := store.Load(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1}, val)
+
+	val, err = store.Load(ctx, "../key/with/slashes")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"b": 2}, val)
+
+	// Every key is a single file without leftover temporary files.
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	assert.NoError(t, store.Delete(ctx, "key"))
+	assert.NoError(t, store.Delete(ctx, "key"))
+
+	_, err = store.Load(ctx, "key")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Values which can't be decoded are reported.
+	assert.NoError(t, os.WriteFile(store.path("invalid"), []byte("{"), 0o644))
+
+	_, err = store.Load(ctx, "invalid")
+	assert.Error(t, err)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = store.Load(cancelled, "key")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, store.Save(cancelled, "key", nil), context.Canceled)
+	assert.ErrorIs(t, store.Delete(cancelled, "key"), context.Canceled)
+}
+
+func TestFileStoreWithCache(t *testing.T) {
+	store, err := NewFileStore[int](t.TempDir())
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	cache, err := New(WithStore[string, int](store), WithCleanupInterval[string, int](0))
+	assert.NoError(t, err)
+
+	assert.NoError(t, cache.Set(ctx, "key", 1))
+	cache.Close()
+
+	// A new cache loads the keys saved by the previous one.
+	cache, err = New(WithStore[string, int](store), WithCleanupInterval[string, int](0))
+	assert.NoError(t, err)
+	defer cache.Close()
+
+	val, err := cache.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, val)
+}
@@ -0,0 +1,118 @@
+package lru
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// FNV-1a constants.
+const (
+	fnvOffset64 uint64 = 14695981039346656037
+	fnvPrime64  uint64 = 1099511628211
+)
+
+// hashKey returns a 64-bit hash of the key.
+// Strings, integers and floats are hashed directly, other key types are hashed by their fmt representation,
+// which is only the same for keys which are == if the type is hashable.
+func hashKey[K comparable](key K) uint64 {
+	switch k := any(key).(type) {
+	case string:
+		return hashString(k)
+	case int:
+		return mix64(uint64(k))
+	case int8:
+		return mix64(uint64(k))
+	case int16:
+		return mix64(uint64(k))
+	case int32:
+		return mix64(uint64(k))
+	case int64:
+		return mix64(uint64(k))
+	case uint:
+		return mix64(uint64(k))
+	case uint8:
+		return mix64(uint64(k))
+	case uint16:
+		return mix64(uint64(k))
+	case uint32:
+		return mix64(uint64(k))
+	case uint64:
+		return mix64(k)
+	case uintptr:
+		return mix64(uint64(k))
+	case float32:
+		return hashFloat(float64(k))
+	case float64:
+		return hashFloat(k)
+	}
+
+	// Named types of floats are formatted like any other float.
+	switch v := reflect.ValueOf(key); v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return hashFloat(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return hashFloat(real(c)) ^ mix64(hashFloat(imag(c)))
+	}
+
+	return hashString(fmt.Sprintf("%#v", key))
+}
+
+// hashFloat returns the hash of the float, 0 and -0 are equal so they're hashed alike.
+func hashFloat(f float64) uint64 {
+	if f == 0 {
+		f = 0
+	}
+	return mix64(math.Float64bits(f))
+}
+
+// hashable reports whether hashKey hashes every two keys of the type which are == alike.
+func hashable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	}
+	return formatsStably(t)
+}
+
+// formatsStably reports whether the values of the type which are == have the same fmt representation.
+// Floats don't as 0 and -0 are ==, neither do pointers and interfaces, which are formatted by what they point to.
+func formatsStably(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	case reflect.Array:
+		return formatsStably(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !formatsStably(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// hashString returns the FNV-1a hash of the string.
+func hashString(s string) uint64 {
+	h := fnvOffset64
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime64
+	}
+	return h
+}
+
+// mix64 is the finalizer of splitmix64, it spreads the bits of integers which are close to each other.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
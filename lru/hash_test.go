@@ -0,0 +1,84 @@
+package lru
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashKey(t *testing.T) {
+	type point struct{ x, y int }
+
+	assert.Equal(t, hashKey("key"), hashKey("key"))
+	assert.NotEqual(t, hashKey("key"), hashKey("key2"))
+
+	assert.Equal(t, hashKey(1), hashKey(1))
+	assert.NotEqual(t, hashKey(1), hashKey(2))
+	assert.NotEqual(t, hashKey(uint8(1)), hashKey(uint8(2)))
+
+	assert.Equal(t, hashKey(point{1, 2}), hashKey(point{1, 2}))
+	assert.NotEqual(t, hashKey(point{1, 2}), hashKey(point{2, 1}))
+
+	// 0 and -0 are == so they're hashed alike, named floats too.
+	type celsius float64
+	negZero := math.Copysign(0, -1)
+	assert.Equal(t, hashKey(0.0), hashKey(negZero))
+	assert.Equal(t, hashKey(float32(0)), hashKey(float32(negZero)))
+	assert.Equal(t, hashKey(celsius(0)), hashKey(celsius(negZero)))
+	assert.Equal(t, hashKey(complex(0, 0)), hashKey(complex(negZero, negZero)))
+	assert.NotEqual(t, hashKey(1.5), hashKey(2.5))
+}
+
+func TestHashable(t *testing.T) {
+	type (
+		point    struct{ x, y int }
+		celsius  float64
+		location struct{ lat, long float64 }
+		node     struct{ next *int }
+	)
+
+	testCases := []struct {
+		key      any
+		hashable bool
+	}{
+		{key: "key", hashable: true},
+		{key: 1, hashable: true},
+		{key: true, hashable: true},
+		{key: 1.5, hashable: true},
+		{key: celsius(1.5), hashable: true},
+		{key: point{}, hashable: true},
+		{key: [2]string{}, hashable: true},
+		{key: location{}, hashable: false},
+		{key: [2]float64{}, hashable: false},
+		{key: new(int), hashable: false},
+		{key: node{}, hashable: false},
+		{key: struct{ v any }{}, hashable: false},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.hashable, hashable(reflect.TypeOf(tc.key)), "%T", tc.key)
+	}
+
+	// Keys which can't be hashed by default require a hasher to have more than one shard.
+	_, err := New(WithShards[location, int](2))
+	assert.EqualError(t, err, "keys of type lru.location require a hasher to be split across shards")
+	_, err = New(WithShards[*int, int](2))
+	assert.EqualError(t, err, "keys of type *int require a hasher to be split across shards")
+
+	cache, err := New(WithShards[location, int](2), WithCleanupInterval[location, int](0), WithHasher[location, int](func(key location) uint64 {
+		return hashFloat(key.lat) ^ hashFloat(key.long)
+	}))
+	assert.NoError(t, err)
+	cache.Close()
+
+	cache, err = New(WithCleanupInterval[location, int](0))
+	assert.NoError(t, err)
+	cache.Close()
+}
+
+func TestHashString(t *testing.T) {
+	assert.Equal(t, fnvOffset64, hashString(""))
+	assert.Equal(t, uint64(0xaf63dc4c8601ec8c), hashString("a"))
+}
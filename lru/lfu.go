@@ -0,0 +1,114 @@
+package lru
+
+import (
+	linkedlist "github.com/MojtabaArezoomand/lru_cache/internal/linked_list"
+)
+
+type (
+	// lfu is a policy which evicts the least frequently used key,
+	// ties are broken by evicting the least recently used one.
+	// All of its operations are O(1) by keeping a list of frequency buckets in ascending order,
+	// each of them holding the keys with that frequency in LRU order.
+	lfu[K comparable] struct {
+		buckets *linkedlist.DoublyLinkedList[uint64, *lfuBucket[K]]
+		items   map[K]lfuItem[K]
+	}
+
+	// lfuBucket holds the keys which are used freq times.
+	lfuBucket[K comparable] struct {
+		freq uint64
+		keys *linkedlist.DoublyLinkedList[K, struct{}]
+	}
+
+	// lfuItem locates a key in its bucket.
+	lfuItem[K comparable] struct {
+		bucket *linkedlist.Node[uint64, *lfuBucket[K]]
+		node   *linkedlist.Node[K, struct{}]
+	}
+)
+
+// NewLFU returns a policy which evicts the least frequently used key.
+func NewLFU[K comparable]() Policy[K] {
+	return &lfu[K]{
+		buckets: linkedlist.NewDoublyLinkedList[uint64, *lfuBucket[K]](),
+		items:   make(map[K]lfuItem[K]),
+	}
+}
+
+// newLFUBucket returns a new empty bucket.
+func newLFUBucket[K comparable](freq uint64) *lfuBucket[K] {
+	return &lfuBucket[K]{freq: freq, keys: linkedlist.NewDoublyLinkedList[K, struct{}]()}
+}
+
+// Add implements Policy interface.
+func (p *lfu[K]) Add(key K) {
+	bucket := p.buckets.Head()
+	if bucket == nil || bucket.GetVal().freq != 1 {
+		bucket = p.buckets.AddToFront(1, newLFUBucket[K](1))
+	}
+
+	p.items[key] = lfuItem[K]{bucket: bucket, node: bucket.GetVal().keys.AddToBack(key, struct{}{})}
+}
+
+// Access implements Policy interface.
+func (p *lfu[K]) Access(key K) {
+	item, ok := p.items[key]
+	if !ok {
+		return
+	}
+
+	freq := item.bucket.GetVal().freq + 1
+
+	next := item.bucket.Next()
+	if next == nil || next.GetVal().freq != freq {
+		next = p.buckets.InsertAfter(item.bucket, freq, newLFUBucket[K](freq))
+	}
+
+	p.unlink(item)
+	p.items[key] = lfuItem[K]{bucket: next, node: next.GetVal().keys.AddToBack(key, struct{}{})}
+}
+
+// Remove implements Policy interface.
+func (p *lfu[K]) Remove(key K) {
+	if item, ok := p.items[key]; ok {
+		p.unlink(item)
+		delete(p.items, key)
+	}
+}
+
+// Evict implements Policy interface.
+func (p *lfu[K]) Evict() (K, bool) {
+	bucket := p.buckets.Head()
+	if bucket == nil {
+		var zero K
+		return zero, false
+	}
+
+	key := bucket.GetVal().keys.Head().GetKey()
+	p.Remove(key)
+
+	return key, true
+}
+
+// Keys implements Policy interface.
+func (p *lfu[K]) Keys() []K {
+	keys := make([]K, 0, len(p.items))
+
+	for bucket := p.buckets.Head(); bucket != nil; bucket = bucket.Next() {
+		for node := bucket.GetVal().keys.Head(); node != nil; node = node.Next() {
+			keys = append(keys, node.GetKey())
+		}
+	}
+
+	return keys
+}
+
+// unlink removes the item's node from its bucket and removes the bucket if it's empty.
+func (p *lfu[K]) unlink(item lfuItem[K]) {
+	keys := item.bucket.GetVal().keys
+	keys.Remove(item.node)
+
+	if keys.Size() == 0 {
+		p.buckets.Remove(item.bucket)
+	}
+}
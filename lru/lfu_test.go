@@ -0,0 +1,65 @@
+package lru
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// frequencies returns the frequency of each bucket of the lfu policy in order.
+func frequencies[K comparable](p *lfu[K]) []uint64 {
+	var freqs []uint64
+	for bucket := p.buckets.Head(); bucket != nil; bucket = bucket.Next() {
+		freqs = append(freqs, bucket.GetVal().freq)
+	}
+	return freqs
+}
+
+func TestLFU(t *testing.T) {
+	p := NewLFU[string]().(*lfu[string])
+
+	_, ok := p.Evict()
+	assert.False(t, ok)
+
+	p.Add("1")
+	p.Add("2")
+	p.Add("3")
+
+	p.Access("1")
+	p.Access("1")
+	p.Access("2")
+	p.Access("not_found")
+
+	assert.Equal(t, []uint64{1, 2, 3}, frequencies(p))
+	assert.Equal(t, []string{"3", "2", "1"}, p.Keys())
+
+	// New keys join the bucket with frequency 1.
+	p.Add("4")
+
+	assert.Equal(t, []uint64{1, 2, 3}, frequencies(p))
+	assert.Equal(t, []string{"3", "4", "2", "1"}, p.Keys())
+
+	// Emptied buckets are removed.
+	p.Access("2")
+
+	assert.Equal(t, []uint64{1, 3}, frequencies(p))
+	assert.Equal(t, []string{"3", "4", "1", "2"}, p.Keys())
+
+	p.Remove("3")
+	p.Remove("not_found")
+
+	// Ties are broken by evicting the least recently used key.
+	key, ok := p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "4", key)
+
+	assert.Equal(t, []uint64{3}, frequencies(p))
+
+	// A new key creates the frequency 1 bucket in front.
+	p.Add("5")
+
+	assert.Equal(t, []uint64{1, 3}, frequencies(p))
+	assert.Equal(t, []string{"5", "1", "2"}, evictAll[string](p))
+	assert.Empty(t, frequencies(p))
+	assert.Empty(t, p.items)
+}
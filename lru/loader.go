@@ -0,0 +1,248 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type (
+	// Loader loads the value of a key which isn't in the cache.
+	// It returns ErrNotFound, or an error wrapping it, if the key doesn't exist in the source.
+	Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+	// negatives caches the errors of keys which weren't found by their loaders.
+	negatives[K comparable] struct {
+		m    sync.Mutex
+		errs map[K]negative
+	}
+
+	// negative is a cached error of a loader.
+	negative struct {
+		err       error
+		expiresAt time.Time
+	}
+)
+
+// GetOrLoad fetches the key from the cache, or loads and stores it on a miss.
+// Loaded keys expire after the cache's default ttl, if there is one.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, loader Loader[K, V]) (V, error) {
+	return c.GetOrLoadWithTTL(ctx, key, c.defaultTTL, loader)
+}
+
+// GetOrLoadWithTTL fetches the key from the cache, or loads and stores it on a miss.
+// Loaded keys expire after ttl, a zero or negative ttl means the key never expires.
+//
+// The loader is called once per key even when many goroutines miss concurrently, the others wait for its result.
+// A waiter whose context is done stops waiting and returns the context's error.
+// If the loader fails because the context of the goroutine which called it is done,
+// the waiters whose contexts aren't done load the key again.
+// If the cache has a refresh interval, stale keys are returned right away and reloaded once in the background.
+// If the loader returns ErrNotFound and the cache has a negative ttl, the error is returned
+// without calling the loader again until the negative ttl passes. Other errors aren't cached.
+// Values which are too large to be stored are still returned.
+func (c *Cache[K, V]) GetOrLoadWithTTL(ctx context.Context, key K, ttl time.Duration, loader Loader[K, V]) (V, error) {
+	if loader == nil {
+		panic("Loader cannot be nil.")
+	}
+
+	if err := checkContext(ctx); err != nil {
+		var zero V
+		return zero, err
+	}
+
+	e, err := c.getOrLoad(ctx, key, ttl, loader)
+	return e.val, err
+}
+
+// getOrLoad fetches the key's entry, or loads and stores it on a miss.
+func (c *Cache[K, V]) getOrLoad(ctx context.Context, key K, ttl time.Duration, loader Loader[K, V]) (entry[V], error) {
+	for {
+		e, err := c.get(key)
+		if err == nil && c.refreshAfter > 0 && e.stale(c.now()) {
+			c.refresh(key, e.version, ttl, loader)
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return e, err
+		}
+
+		if err := c.negatives.get(key, c.now()); err != nil {
+			return entry[V]{}, err
+		}
+
+		e, shared, err := c.loads.do(ctx, key, func() (entry[V], error) {
+			return c.load(ctx, key, ttl, loader)
+		})
+		if shared && isContextError(err) && ctx.Err() == nil {
+			continue
+		}
+
+		return e, err
+	}
+}
+
+// load calls the loader and stores its result.
+func (c *Cache[K, V]) load(ctx context.Context, key K, ttl time.Duration, loader Loader[K, V]) (entry[V], error) {
+	var evictions []eviction[K, V]
+	defer func() { c.notify(evictions) }()
+
+	// Writes of the key wait for the load, so a value loaded from the store can't overwrite a newer one.
+	if c.store != nil {
+		defer c.keys.lock(key)()
+	}
+
+	// The key may have been loaded by a call which finished after this goroutine missed it.
+	s := c.shard(key)
+	if e, ok := s.peek(key); ok {
+		return e, nil
+	}
+
+	val, err := loader(ctx, key)
+	if err != nil {
+		if c.negativeTTL > 0 && errors.Is(err, ErrNotFound) {
+			c.negatives.set(key, err, c.now().Add(c.negativeTTL))
+		}
+		return entry[V]{}, err
+	}
+
+	// A key set while the loader ran is newer than the loaded value, so the load returns it instead.
+	e := c.newEntry(key, val)
+	ok, evictions, err := s.setIf(key, e, ttl, func(old *entry[V]) error {
+		if old != nil {
+			return errSkipped
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrTooLarge) {
+		return entry[V]{}, err
+	}
+	if !ok && err == nil {
+		if cur, found := s.peek(key); found {
+			return cur, nil
+		}
+	}
+
+	return *e, nil
+}
+
+// refresh reloads the stale key of the given version in the background, unless the key is already being loaded.
+func (c *Cache[K, V]) refresh(key K, version uint64, ttl time.Duration, loader Loader[K, V]) {
+	c.loads.doAsync(key, func() (entry[V], error) {
+		return c.reload(key, version, ttl, loader)
+	})
+}
+
+// reload calls the loader and replaces the key's stale value with its result.
+// The stale value is kept if the loader fails, and removed if the key doesn't exist anymore.
+// Nothing is changed if the key was written since its stale version was read, the write is newer than the loaded value.
+func (c *Cache[K, V]) reload(key K, version uint64, ttl time.Duration, loader Loader[K, V]) (e entry[V], err error) {
+	// Nobody would recover a panic of the background goroutine.
+	defer func() {
+		if r := recover(); r != nil {
+			e, err = entry[V]{}, errLoaderPanicked
+		}
+	}()
+
+	var evictions []eviction[K, V]
+	defer func() { c.notify(evictions) }()
+
+	if c.store != nil {
+		defer c.keys.lock(key)()
+	}
+
+	unchanged := func(old *entry[V]) error {
+		if old == nil || old.version != version {
+			return errSkipped
+		}
+		return nil
+	}
+
+	s := c.shard(key)
+	val, err := loader(context.Background(), key)
+	if errors.Is(err, ErrNotFound) {
+		var deleted bool
+		if deleted, evictions, _ = s.deleteIf(key, unchanged); deleted && c.negativeTTL > 0 {
+			c.negatives.set(key, err, c.now().Add(c.negativeTTL))
+		}
+		return entry[V]{}, err
+	} else if err != nil {
+		return entry[V]{}, err
+	}
+
+	ne := c.newEntry(key, val)
+	ok, evictions, err := s.setIf(key, ne, ttl, unchanged)
+	if err != nil && !errors.Is(err, ErrTooLarge) {
+		return entry[V]{}, err
+	}
+	if !ok && err == nil {
+		if cur, found := s.peek(key); found {
+			return cur, nil
+		}
+	}
+
+	return *ne, nil
+}
+
+// isContextError reports whether the error is caused by a done context.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// get returns the key's cached error, it's nil if there is none or it's expired.
+func (n *negatives[K]) get(key K, now time.Time) error {
+	n.m.Lock()
+	defer n.m.Unlock()
+
+	neg, ok := n.errs[key]
+	if !ok {
+		return nil
+	}
+
+	if !now.Before(neg.expiresAt) {
+		delete(n.errs, key)
+		return nil
+	}
+
+	return neg.err
+}
+
+// delete removes the key's error, if there is one.
+func (n *negatives[K]) delete(key K) {
+	n.m.Lock()
+	defer n.m.Unlock()
+
+	delete(n.errs, key)
+}
+
+// set caches the key's error until it expires.
+func (n *negatives[K]) set(key K, err error, expiresAt time.Time) {
+	n.m.Lock()
+	defer n.m.Unlock()
+
+	if n.errs == nil {
+		n.errs = make(map[K]negative)
+	}
+
+	n.errs[key] = negative{err: err, expiresAt: expiresAt}
+}
+
+// deleteExpired removes the expired errors.
+func (n *negatives[K]) deleteExpired(now time.Time) {
+	n.m.Lock()
+	defer n.m.Unlock()
+
+	for key, neg := range n.errs {
+		if !now.Before(neg.expiresAt) {
+			delete(n.errs, key)
+		}
+	}
+}
+
+// flush removes all of the errors.
+func (n *negatives[K]) flush() {
+	n.m.Lock()
+	defer n.m.Unlock()
+
+	n.errs = nil
+}
@@ -0,0 +1,431 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingLoader returns a loader which counts its calls and returns the key's length.
+func countingLoader(calls *int32) Loader[string, int] {
+	return func(ctx context.Context, key string) (int, error) {
+		atomic.AddInt32(calls, 1)
+		return len(key), nil
+	}
+}
+
+func TestGetOrLoad(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t, WithDefaultTTL[string, int](time.Minute), WithClock[string, int](clock.Now))
+
+	ctx := context.Background()
+
+	var calls int32
+	loader := countingLoader(&calls)
+
+	assert.NoError(t, cache.Set(ctx, "cached", 100))
+
+	val, err := cache.GetOrLoad(ctx, "cached", loader)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, val)
+	assert.Zero(t, calls)
+
+	// Misses are loaded and stored with the default ttl.
+	val, err = cache.GetOrLoad(ctx, "key", loader)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, val)
+	assert.Equal(t, int32(1), calls)
+
+	item, err := cache.GetItem(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, clock.now.Add(time.Minute), item.ExpiresAt)
+
+	val, err = cache.GetOrLoadWithTTL(ctx, "long_key", time.Hour, loader)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, val)
+
+	item, err = cache.GetItem(ctx, "long_key")
+	assert.NoError(t, err)
+	assert.Equal(t, clock.now.Add(time.Hour), item.ExpiresAt)
+
+	// Errors aren't stored.
+	failed := errors.New("failed")
+	_, err = cache.GetOrLoad(ctx, "failing", func(ctx context.Context, key string) (int, error) {
+		return 0, failed
+	})
+	assert.ErrorIs(t, err, failed)
+	assert.NotContains(t, cache.shards[0].storage, "failing")
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = cache.GetOrLoad(cancelled, "other", loader)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(2), calls)
+
+	assert.Panics(t, func() {
+		cache.GetOrLoad(ctx, "key", nil)
+	})
+}
+
+func TestGetOrLoadConcurrently(t *testing.T) {
+	cache := newTestCache(t)
+
+	var calls int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key string) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 1, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			val, err := cache.GetOrLoad(context.Background(), "key", loader)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, val)
+		}()
+	}
+
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	wg.Wait()
+
+	// Goroutines which missed the key while it was loaded waited for the same call.
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestGetOrLoadCancelledLoader(t *testing.T) {
+	cache := newTestCache(t)
+
+	var calls int32
+	started := make(chan struct{})
+	loader := func(ctx context.Context, key string) (int, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return 1, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		_, err := cache.GetOrLoad(ctx, "key", loader)
+		assert.ErrorIs(t, err, context.Canceled)
+	}()
+	<-started
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	// The waiter loads the key again instead of failing with the other goroutine's error.
+	val, err := cache.GetOrLoad(context.Background(), "key", loader)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, val)
+
+	wg.Wait()
+}
+
+func TestGetOrLoadNegativeTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t, WithNegativeTTL[string, int](time.Second), WithClock[string, int](clock.Now))
+
+	ctx := context.Background()
+
+	var calls int32
+	loader := func(ctx context.Context, key string) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		if key == "missing" {
+			return 0, fmt.Errorf("user %s: %w", key, ErrNotFound)
+		}
+		return 0, errors.New("failed")
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := cache.GetOrLoad(ctx, "missing", loader)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.EqualError(t, err, "user missing: not found")
+	}
+	assert.Equal(t, int32(1), calls)
+
+	// Other errors aren't cached.
+	for i := 0; i < 3; i++ {
+		_, err := cache.GetOrLoad(ctx, "failing", loader)
+		assert.EqualError(t, err, "failed")
+	}
+	assert.Equal(t, int32(4), calls)
+
+	// The error is loaded again once the negative ttl passes.
+	clock.Advance(time.Second)
+
+	_, err := cache.GetOrLoad(ctx, "missing", loader)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, int32(5), calls)
+
+	// Expired errors are removed in the background and flushing removes all of them.
+	clock.Advance(time.Second)
+	cache.deleteExpired()
+	assert.Empty(t, cache.negatives.errs)
+
+	_, err = cache.GetOrLoad(ctx, "missing", loader)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Len(t, cache.negatives.errs, 1)
+
+	assert.NoError(t, cache.Flush(ctx))
+	assert.Empty(t, cache.negatives.errs)
+}
+
+func TestGetOrLoadWithoutNegativeTTL(t *testing.T) {
+	cache := newTestCache(t)
+
+	var calls int32
+	loader := func(ctx context.Context, key string) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 0, ErrNotFound
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := cache.GetOrLoad(context.Background(), "missing", loader)
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assert.Equal(t, int32(3), calls)
+}
+
+func TestGetOrLoadTooLarge(t *testing.T) {
+	cache := newTestCache(t, WithMaxBytes[string, int](10), WithSizer(func(key string, val int) uint64 { return uint64(val) }))
+
+	// The value is returned even though it can't be stored.
+	val, err := cache.GetOrLoad(context.Background(), "key", func(ctx context.Context, key string) (int, error) {
+		return 20, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 20, val)
+	assert.Empty(t, cache.shards[0].storage)
+}
+
+func TestGetOrLoadConcurrentSet(t *testing.T) {
+	cache := newTestCache(t)
+
+	ctx := context.Background()
+
+	started, gate := make(chan struct{}), make(chan struct{})
+	done := make(chan int)
+	go func() {
+		val, err := cache.GetOrLoad(ctx, "key", func(ctx context.Context, key string) (int, error) {
+			close(started)
+			<-gate
+			return 1, nil
+		})
+		assert.NoError(t, err)
+		done <- val
+	}()
+
+	// A key set while it's being loaded isn't overwritten by the older loaded value.
+	<-started
+	assert.NoError(t, cache.Set(ctx, "key", 2))
+	close(gate)
+	assert.Equal(t, 2, <-done)
+
+	val, err := cache.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, val)
+}
+
+func TestGetOrLoadRefresh(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t,
+		WithDefaultTTL[string, int](time.Minute),
+		WithRefreshAfter[string, int](10*time.Second),
+		WithClock[string, int](clock.Now),
+	)
+
+	ctx := context.Background()
+
+	// Every call of the loader waits for the gate and returns the number of calls so far.
+	var calls int32
+	gate := make(chan struct{}, 1)
+	loader := func(ctx context.Context, key string) (int, error) {
+		<-gate
+		return int(atomic.AddInt32(&calls, 1)), nil
+	}
+
+	gate <- struct{}{}
+	val, err := cache.GetOrLoad(ctx, "key", loader)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, val)
+
+	item, err := cache.GetItem(ctx, "key")
+	assert.NoError(t, err)
+	assert.False(t, item.Stale)
+
+	clock.Advance(10 * time.Second)
+
+	// Stale keys are returned without waiting for the refresh, which is only started once.
+	for i := 0; i < 3; i++ {
+		val, err = cache.GetOrLoad(ctx, "key", loader)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, val)
+	}
+
+	item, err = cache.GetItem(ctx, "key")
+	assert.NoError(t, err)
+	assert.True(t, item.Stale)
+
+	gate <- struct{}{}
+	assert.Eventually(t, func() bool {
+		item, err := cache.GetItem(ctx, "key")
+		return err == nil && item.Value == 2 && !item.Stale
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// The refreshed key expires after its own ttl.
+	item, err = cache.GetItem(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, clock.Now().Add(time.Minute), item.ExpiresAt)
+
+	// Expired keys wait for the loader.
+	clock.Advance(time.Minute)
+
+	gate <- struct{}{}
+	val, err = cache.GetOrLoad(ctx, "key", loader)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, val)
+}
+
+func TestGetOrLoadRefreshErrors(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t, WithRefreshAfter[string, int](time.Second), WithClock[string, int](clock.Now))
+
+	ctx := context.Background()
+
+	assert.NoError(t, cache.Set(ctx, "failing", 1))
+	assert.NoError(t, cache.Set(ctx, "panicking", 2))
+	assert.NoError(t, cache.Set(ctx, "deleted", 3))
+
+	clock.Advance(time.Second)
+
+	var calls int32
+	refreshed := make(chan struct{}, 3)
+	loader := func(ctx context.Context, key string) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		defer func() { refreshed <- struct{}{} }()
+
+		switch key {
+		case "failing":
+			return 0, errors.New("failed")
+		case "panicking":
+			panic("boom")
+		default:
+			return 0, ErrNotFound
+		}
+	}
+
+	for _, key := range []string{"failing", "panicking", "deleted"} {
+		_, err := cache.GetOrLoad(ctx, key, loader)
+		assert.NoError(t, err)
+		<-refreshed
+	}
+
+	// Failed refreshes keep the stale values, keys which don't exist anymore are removed.
+	assert.Eventually(t, func() bool {
+		_, err := cache.GetItem(ctx, "deleted")
+		return errors.Is(err, ErrNotFound)
+	}, time.Second, time.Millisecond)
+
+	for key, want := range map[string]int{"failing": 1, "panicking": 2} {
+		item, err := cache.GetItem(ctx, key)
+		assert.NoError(t, err)
+		assert.Equal(t, want, item.Value)
+		assert.True(t, item.Stale)
+	}
+}
+
+func TestGetOrLoadRefreshConcurrentSet(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t,
+		WithRefreshAfter[string, int](time.Second),
+		WithNegativeTTL[string, int](time.Minute),
+		WithClock[string, int](clock.Now),
+	)
+
+	ctx := context.Background()
+
+	assert.NoError(t, cache.Set(ctx, "key", 1))
+	assert.NoError(t, cache.Set(ctx, "deleted", 1))
+
+	clock.Advance(time.Second)
+
+	started, gate := make(chan struct{}, 2), make(chan struct{})
+	loader := func(ctx context.Context, key string) (int, error) {
+		started <- struct{}{}
+		<-gate
+
+		if key == "deleted" {
+			return 0, ErrNotFound
+		}
+		return 2, nil
+	}
+
+	for _, key := range []string{"key", "deleted"} {
+		_, err := cache.GetOrLoad(ctx, key, loader)
+		assert.NoError(t, err)
+		<-started
+	}
+
+	// Keys written during their refresh are neither overwritten nor removed by it.
+	assert.NoError(t, cache.Set(ctx, "key", 3))
+	assert.NoError(t, cache.Set(ctx, "deleted", 3))
+	close(gate)
+
+	assert.Eventually(t, func() bool {
+		cache.loads.m.Lock()
+		defer cache.loads.m.Unlock()
+		return len(cache.loads.calls) == 0
+	}, time.Second, time.Millisecond)
+
+	for _, key := range []string{"key", "deleted"} {
+		val, err := cache.GetOrLoad(ctx, key, loader)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, val)
+	}
+}
+
+func TestGetRefreshWithStore(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	store := NewMemoryStore[string, int]()
+	cache := newTestCache(t,
+		WithStore[string, int](store),
+		WithRefreshAfter[string, int](time.Second),
+		WithClock[string, int](clock.Now),
+	)
+
+	ctx := context.Background()
+
+	assert.NoError(t, cache.Set(ctx, "key", 1))
+	assert.NoError(t, store.Save(ctx, "key", 2))
+
+	clock.Advance(time.Second)
+
+	val, err := cache.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, val)
+
+	assert.Eventually(t, func() bool {
+		val, err := cache.Get(ctx, "key")
+		return err == nil && val == 2
+	}, time.Second, time.Millisecond)
+}
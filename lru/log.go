@@ -0,0 +1,409 @@
+package lru
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FsyncPolicy is how often the log is flushed to disk.
+type FsyncPolicy string
+
+// Fsync policies.
+const (
+	// FsyncAlways flushes every record before the write returns, no acknowledged write is lost.
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncEverySecond flushes once a second, up to a second of writes is lost on a crash of the machine.
+	FsyncEverySecond FsyncPolicy = "everysec"
+	// FsyncNever leaves flushing to the operating system.
+	FsyncNever FsyncPolicy = "never"
+)
+
+// Log operations.
+const (
+	logSet    = "set"
+	logDelete = "del"
+	logFlush  = "flush"
+)
+
+// defaultCompactMinSize is the size a log must reach before it's compacted automatically.
+const defaultCompactMinSize = 1 << 20
+
+type (
+	// Log is an append-only log of the writes of a cache, see WithLog.
+	// Every record is a JSON value on its own line, so a record torn by a crash is detected and dropped.
+	Log[K comparable, V any] struct {
+		path  string
+		fsync FsyncPolicy
+
+		m    sync.Mutex
+		f    *os.File
+		size int64
+		// base is the size of the log after it was opened or compacted, it's compacted again once it doubles.
+		base  int64
+		dirty bool
+		// rewrite buffers the records appended while the log is compacted, nil if it isn't.
+		rewrite *bytes.Buffer
+
+		// compacting serializes the compactions.
+		compacting sync.Mutex
+		// compactMinSize is the size the log must reach before it's compacted automatically.
+		compactMinSize int64
+		// compact asks the cache to compact the log.
+		compact chan struct{}
+
+		stop      chan struct{}
+		done      chan struct{}
+		closeOnce sync.Once
+	}
+
+	// logRecord is a write of a log.
+	logRecord[K comparable, V any] struct {
+		Op        string     `json:"op"`
+		Key       K          `json:"key,omitempty"`
+		Value     *V         `json:"value,omitempty"`
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	}
+)
+
+// OpenLog opens the log at path, it's created if it doesn't exist.
+// Close must be called once the cache which writes to the log is closed.
+func OpenLog[K comparable, V any](path string, fsync FsyncPolicy) (*Log[K, V], error) {
+	switch fsync {
+	case FsyncAlways, FsyncEverySecond, FsyncNever:
+	default:
+		return nil, fmt.Errorf("unknown fsync policy %q", fsync)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	l := Log[K, V]{
+		path:           path,
+		fsync:          fsync,
+		f:              f,
+		size:           info.Size(),
+		base:           info.Size(),
+		compactMinSize: defaultCompactMinSize,
+		compact:        make(chan struct{}, 1),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+
+	if fsync == FsyncEverySecond {
+		go l.syncEvery(time.Second)
+	} else {
+		close(l.done)
+	}
+
+	return &l, nil
+}
+
+// Size returns the size of the log in bytes.
+func (l *Log[K, V]) Size() int64 {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	return l.size
+}
+
+// Close flushes the log to disk and closes it.
+func (l *Log[K, V]) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.stop)
+	})
+	<-l.done
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.f == nil {
+		return nil
+	}
+
+	err := l.f.Sync()
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f = nil
+
+	return err
+}
+
+// syncEvery flushes the log to disk every interval if it's written, until the log is closed.
+func (l *Log[K, V]) syncEvery(interval time.Duration) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.m.Lock()
+			if l.dirty && l.f != nil {
+				_ = l.f.Sync()
+				l.dirty = false
+			}
+			l.m.Unlock()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// append writes the record to the log, it asks for a compaction once the log doubles in size.
+func (l *Log[K, V]) append(rec logRecord[K, V]) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.f == nil {
+		return os.ErrClosed
+	}
+
+	n, err := l.f.Write(b)
+	l.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	if l.rewrite != nil {
+		l.rewrite.Write(b)
+	}
+
+	if l.fsync == FsyncAlways {
+		if err := l.f.Sync(); err != nil {
+			return err
+		}
+	} else {
+		l.dirty = true
+	}
+
+	if l.size >= l.compactMinSize && l.size >= 2*l.base {
+		select {
+		case l.compact <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// replay calls apply with every record of the log in order.
+// A torn record at the end of the log is dropped, any other invalid record is an error.
+func (l *Log[K, V]) replay(apply func(rec logRecord[K, V]) error) error {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if _, err := l.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(l.f)
+	var offset int64
+
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) == 0 {
+				return nil
+			}
+
+			// The last record was torn while it was written, so it was never acknowledged.
+			if err := l.f.Truncate(offset); err != nil {
+				return err
+			}
+			l.size, l.base = offset, offset
+			return nil
+		} else if err != nil {
+			return err
+		}
+		offset += int64(len(line))
+
+		var rec logRecord[K, V]
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("invalid log record %d: %w", n, err)
+		}
+
+		if err := apply(rec); err != nil {
+			return fmt.Errorf("invalid log record %d: %w", n, err)
+		}
+	}
+}
+
+// startRewrite starts buffering the appended records for the compaction which is about to start.
+func (l *Log[K, V]) startRewrite() {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.rewrite = &bytes.Buffer{}
+}
+
+// finishRewrite replaces the log with the entries followed by the records appended since startRewrite.
+func (l *Log[K, V]) finishRewrite(entries []snapshotEntry[K, V]) (err error) {
+	defer func() {
+		if err != nil {
+			l.m.Lock()
+			l.rewrite = nil
+			l.m.Unlock()
+		}
+	}()
+
+	f, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for i := range entries {
+		se := &entries[i]
+		if err := enc.Encode(logRecord[K, V]{Op: logSet, Key: se.Key, Value: &se.Value, ExpiresAt: se.ExpiresAt}); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.f == nil {
+		f.Close()
+		return os.ErrClosed
+	}
+
+	if _, err := f.Write(l.rewrite.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := os.Rename(f.Name(), l.path); err != nil {
+		f.Close()
+		return err
+	}
+
+	// The renamed file is appended to from now on, it's already at its end.
+	l.f.Close()
+	l.f = f
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	l.size, l.base = info.Size(), info.Size()
+	l.dirty = false
+	l.rewrite = nil
+
+	return nil
+}
+
+// replayLog applies the records of the cache's log to the cache, without appending them again.
+func (c *Cache[K, V]) replayLog() error {
+	return c.log.replay(func(rec logRecord[K, V]) error {
+		switch rec.Op {
+		case logSet:
+			var ttl time.Duration
+			if rec.ExpiresAt != nil {
+				if ttl = rec.ExpiresAt.Sub(c.now()); ttl <= 0 {
+					return nil
+				}
+			}
+
+			var val V
+			if rec.Value != nil {
+				val = *rec.Value
+			}
+
+			if err := c.set(rec.Key, val, ttl); err != nil && !errors.Is(err, ErrTooLarge) {
+				return err
+			}
+		case logDelete:
+			if err := c.delete(rec.Key); err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+		case logFlush:
+			c.notify(c.flush())
+		default:
+			return fmt.Errorf("unknown operation %q", rec.Op)
+		}
+
+		return nil
+	})
+}
+
+// logSetRecord returns the log record of setting the key-value with the given ttl.
+func (c *Cache[K, V]) logSetRecord(key K, val V, ttl time.Duration) logRecord[K, V] {
+	rec := logRecord[K, V]{Op: logSet, Key: key, Value: &val}
+	if ttl > 0 {
+		expiresAt := c.now().Add(ttl)
+		rec.ExpiresAt = &expiresAt
+	}
+	return rec
+}
+
+// CompactLog rewrites the cache's log from the cache's current keys, so it only holds one record per key.
+// The cache keeps serving reads and writes while the log is rewritten, it's a no-op if the cache has no log.
+func (c *Cache[K, V]) CompactLog(ctx context.Context) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	if c.log == nil {
+		return nil
+	}
+
+	c.log.compacting.Lock()
+	defer c.log.compacting.Unlock()
+
+	// Writes are paused while the keys are copied, so every write is either in the copy or in the rewrite buffer.
+	c.logm.Lock()
+	entries := c.snapshot()
+	c.log.startRewrite()
+	c.logm.Unlock()
+
+	return c.log.finishRewrite(entries)
+}
+
+// compactLogs compacts the log whenever it asks for it, until the cache is closed.
+func (c *Cache[K, V]) compactLogs() {
+	for {
+		select {
+		case <-c.log.compact:
+			_ = c.CompactLog(context.Background())
+		case <-c.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package lru
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestLog opens a log at path which is closed when the test finishes.
+func newTestLog(t *testing.T, path string, fsync FsyncPolicy) *Log[string, int] {
+	log, err := OpenLog[string, int](path, fsync)
+	assert.NoError(t, err)
+	t.Cleanup(func() { log.Close() })
+
+	return log
+}
+
+// reopen closes the cache and its log, and returns a new cache which replays the log.
+func reopen(t *testing.T, cache *Cache[string, int], path string, opts ...Option[string, int]) *Cache[string, int] {
+	cache.Close()
+	assert.NoError(t, cache.log.Close())
+
+	return newTestCache(t, append(opts, WithLog(newTestLog(t, path, FsyncAlways)))...)
+}
+
+func TestOpenLog(t *testing.T) {
+	_, err := OpenLog[string, int](filepath.Join(t.TempDir(), "cache.log"), "sometimes")
+	assert.EqualError(t, err, `unknown fsync policy "sometimes"`)
+
+	_, err = OpenLog[string, int](filepath.Join(t.TempDir(), "missing", "cache.log"), FsyncNever)
+	assert.Error(t, err)
+
+	log := newTestLog(t, filepath.Join(t.TempDir(), "cache.log"), FsyncNever)
+	assert.NoError(t, log.Close())
+	assert.NoError(t, log.Close())
+
+	assert.ErrorIs(t, log.append(logRecord[string, int]{Op: logFlush}), os.ErrClosed)
+}
+
+func TestLog(t *testing.T) {
+	for _, fsync := range []FsyncPolicy{FsyncAlways, FsyncEverySecond, FsyncNever} {
+		t.Run(string(fsync), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cache.log")
+			clock := &fakeClock{now: time.Unix(1000, 0)}
+			cache := newTestCache(t, WithLog(newTestLog(t, path, fsync)), WithClock[string, int](clock.Now))
+
+			ctx := context.Background()
+
+			assert.NoError(t, cache.Set(ctx, "flushed", 1))
+			assert.NoError(t, cache.Flush(ctx))
+			assert.NoError(t, cache.Set(ctx, "a", 1))
+			assert.NoError(t, cache.SetWithTTL(ctx, "b", 2, time.Minute))
+			assert.NoError(t, cache.SetWithTTL(ctx, "expired", 3, time.Second))
+			assert.NoError(t, cache.Set(ctx, "deleted", 4))
+			assert.NoError(t, cache.Set(ctx, "a", 5))
+			assert.NoError(t, cache.Delete(ctx, "deleted"))
+
+			// Failed writes aren't logged.
+			assert.ErrorIs(t, cache.Delete(ctx, "missing"), ErrNotFound)
+
+			clock.Advance(time.Second)
+			cache = reopen(t, cache, path, WithClock[string, int](clock.Now))
+
+			assert.Equal(t, []string{"b", "a"}, cache.shards[0].policy.Keys())
+
+			val, err := cache.Get(ctx, "a")
+			assert.NoError(t, err)
+			assert.Equal(t, 5, val)
+
+			item, err := cache.GetItem(ctx, "b")
+			assert.NoError(t, err)
+			assert.Equal(t, time.Unix(1060, 0), item.ExpiresAt)
+		})
+	}
+}
+
+func TestLogReplayErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.log")
+
+	// A torn record at the end is dropped.
+	records := `{"op":"set","key":"a","value":1}` + "\n" + `{"op":"set","key":"b","val`
+	assert.NoError(t, os.WriteFile(path, []byte(records), 0o644))
+
+	log := newTestLog(t, path, FsyncAlways)
+	cache := newTestCache(t, WithLog(log))
+
+	assert.Equal(t, []string{"a"}, cache.shards[0].policy.Keys())
+	assert.Equal(t, int64(len(`{"op":"set","key":"a","value":1}`)+1), log.Size())
+
+	// New records are appended after the last valid one.
+	assert.NoError(t, cache.Set(context.Background(), "c", 3))
+	cache = reopen(t, cache, path)
+	assert.Equal(t, []string{"a", "c"}, cache.shards[0].policy.Keys())
+
+	testCases := []struct {
+		name    string
+		records string
+		err     string
+	}{
+		{name: "invalid_record", records: `{"op":"flush"}` + "\n{\n" + `{"op":"flush"}` + "\n", err: "invalid log record 2: unexpected end of JSON input"},
+		{name: "unknown_operation", records: `{"op":"incr","key":"a"}` + "\n", err: `invalid log record 1: unknown operation "incr"`},
+		{name: "invalid_value", records: `{"op":"set","key":"a","value":"1"}` + "\n", err: "invalid log record 1: json: cannot unmarshal string"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cache.log")
+			assert.NoError(t, os.WriteFile(path, []byte(tc.records), 0o644))
+
+			_, err := New(WithLog(newTestLog(t, path, FsyncNever)))
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestCompactLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.log")
+	log := newTestLog(t, path, FsyncAlways)
+	cache := newTestCache(t, WithLog(log))
+
+	ctx := context.Background()
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, cache.Set(ctx, "a", i))
+		assert.NoError(t, cache.Set(ctx, "b", i))
+	}
+	assert.NoError(t, cache.Delete(ctx, "b"))
+	assert.NoError(t, cache.Set(ctx, "c", 1))
+
+	before := log.Size()
+	assert.NoError(t, cache.CompactLog(ctx))
+	assert.Less(t, log.Size(), before/10)
+
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"op":"set","key":"a","value":99}
+{"op":"set","key":"c","value":1}
+`, string(b))
+
+	// Writes which happen while the log is rewritten are kept.
+	entries := cache.snapshot()
+	log.startRewrite()
+	assert.NoError(t, cache.Set(ctx, "d", 4))
+	assert.NoError(t, log.finishRewrite(entries))
+	assert.NoError(t, cache.Set(ctx, "e", 5))
+
+	cache = reopen(t, cache, path)
+	assert.Equal(t, []string{"a", "c", "d", "e"}, cache.shards[0].policy.Keys())
+
+	// Only the log file is left in the directory.
+	files, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	assert.NoError(t, newTestCache(t).CompactLog(ctx))
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, cache.CompactLog(cancelled), context.Canceled)
+}
+
+func TestCompactLogAutomatically(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.log")
+	log := newTestLog(t, path, FsyncNever)
+	log.compactMinSize = 512
+
+	cache := newTestCache(t, WithLog(log))
+
+	ctx := context.Background()
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, cache.Set(ctx, "key", i))
+	}
+
+	// The log is compacted once it reaches the min size, and again once it doubles.
+	assert.Eventually(t, func() bool {
+		return log.Size() < 512
+	}, time.Second, time.Millisecond)
+
+	cache = reopen(t, cache, path)
+
+	val, err := cache.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 99, val)
+}
+
+func TestLogConditionalWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.log")
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t, WithLog(newTestLog(t, path, FsyncAlways)), WithClock[string, int](clock.Now))
+
+	ctx := context.Background()
+
+	ok, err := cache.SetIf(ctx, "a", 1, SetIfAbsent)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// Writes whose condition doesn't hold aren't logged.
+	ok, err = cache.SetIf(ctx, "a", 2, SetIfAbsent)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, cache.Expire(ctx, "a", time.Minute))
+
+	cache = reopen(t, cache, path, WithClock[string, int](clock.Now))
+
+	item, err := cache.GetItem(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, item.Value)
+	assert.Equal(t, time.Unix(1060, 0), item.ExpiresAt)
+}
+
+func TestLogOnEvict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.log")
+
+	var cache *Cache[string, int]
+	cache = newTestCache(t, WithLog(newTestLog(t, path, FsyncAlways)), WithOnEvict(func(key string, val int, reason EvictionReason) {
+		// Writing to the cache would deadlock if the log's lock was still held.
+		if reason == EvictionReasonFlushed {
+			assert.NoError(t, cache.Set(context.Background(), "flushed_"+key, val))
+		}
+	}))
+
+	ctx := context.Background()
+
+	assert.NoError(t, cache.Set(ctx, "key", 1))
+	assert.NoError(t, cache.Flush(ctx))
+
+	// The writes of the callbacks are logged after the flush.
+	cache = reopen(t, cache, path)
+
+	_, err := cache.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrNotFound)
+	v, err := cache.Get(ctx, "flushed_key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+}
@@ -0,0 +1,56 @@
+package lru
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore is a Store which keeps the key-values in a map, it's mostly useful for testing.
+type MemoryStore[K comparable, V any] struct {
+	m    sync.RWMutex
+	data map[K]V
+}
+
+// NewMemoryStore returns a new empty memory store.
+func NewMemoryStore[K comparable, V any]() *MemoryStore[K, V] {
+	return &MemoryStore[K, V]{data: make(map[K]V)}
+}
+
+// Load implements Store interface.
+func (s *MemoryStore[K, V]) Load(ctx context.Context, key K) (V, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	val, ok := s.data[key]
+	if !ok {
+		return val, ErrNotFound
+	}
+
+	return val, nil
+}
+
+// Save implements Store interface.
+func (s *MemoryStore[K, V]) Save(ctx context.Context, key K, val V) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.data[key] = val
+	return nil
+}
+
+// Delete implements Store interface.
+func (s *MemoryStore[K, V]) Delete(ctx context.Context, key K) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	delete(s.data, key)
+	return nil
+}
+
+// Len returns the number of stored keys.
+func (s *MemoryStore[K, V]) Len() int {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	return len(s.data)
+}
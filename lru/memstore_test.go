@@ -0,0 +1,29 @@
+package lru
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore[string, int]()
+
+	ctx := context.Background()
+
+	_, err := store.Load(ctx, "key")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, store.Save(ctx, "key", 1))
+	assert.NoError(t, store.Save(ctx, "key", 2))
+
+	val, err := store.Load(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, val)
+	assert.Equal(t, 1, store.Len())
+
+	assert.NoError(t, store.Delete(ctx, "key"))
+	assert.NoError(t, store.Delete(ctx, "key"))
+	assert.Zero(t, store.Len())
+}
@@ -0,0 +1,226 @@
+package lru
+
+import (
+	"errors"
+	"time"
+)
+
+// Defaults used when the corresponding option isn't given.
+const (
+	DefaultCapacity        uint64        = 2048
+	DefaultCleanupInterval time.Duration = time.Minute
+)
+
+// Option configures a cache created by New.
+type Option[K comparable, V any] func(*Cache[K, V]) error
+
+// WithCapacity sets the maximum number of keys the cache holds.
+func WithCapacity[K comparable, V any](capacity uint64) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		if capacity == 0 {
+			return errors.New("capacity must be greater than 0")
+		}
+
+		c.capacity = capacity
+		return nil
+	}
+}
+
+// WithPolicy sets the built-in eviction policy, it defaults to PolicyLRU.
+func WithPolicy[K comparable, V any](name PolicyName) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		c.policyFunc = func(capacity uint64) (Policy[K], error) {
+			return newPolicy[K](name, capacity)
+		}
+		return nil
+	}
+}
+
+// WithPolicyFunc sets a function which creates a custom eviction policy for a cache with the given capacity.
+// It's called once by New and again whenever the cache is flushed.
+func WithPolicyFunc[K comparable, V any](newPolicy func(capacity uint64) Policy[K]) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		if newPolicy == nil {
+			return errors.New("policy func cannot be nil")
+		}
+
+		c.policyFunc = func(capacity uint64) (Policy[K], error) {
+			return newPolicy(capacity), nil
+		}
+		return nil
+	}
+}
+
+// WithMaxBytes bounds the total cost of the stored keys, as computed by the sizer given by WithSizer.
+// Keys are evicted until both the capacity and max bytes are respected,
+// and values which cost more than maxBytes on their own are rejected with ErrTooLarge.
+func WithMaxBytes[K comparable, V any](maxBytes uint64) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		if maxBytes == 0 {
+			return errors.New("max bytes must be greater than 0")
+		}
+
+		c.maxBytes = maxBytes
+		return nil
+	}
+}
+
+// WithSizer sets the function which computes the cost of a key-value in bytes.
+func WithSizer[K comparable, V any](sizer func(key K, val V) uint64) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		if sizer == nil {
+			return errors.New("sizer cannot be nil")
+		}
+
+		c.sizer = sizer
+		return nil
+	}
+}
+
+// WithDefaultTTL sets the ttl of keys stored by Set.
+// Keys stored by Set never expire if it's not given.
+func WithDefaultTTL[K comparable, V any](ttl time.Duration) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		if ttl < 0 {
+			return errors.New("default ttl cannot be negative")
+		}
+
+		c.defaultTTL = ttl
+		return nil
+	}
+}
+
+// WithNegativeTTL sets how long GetOrLoad caches the ErrNotFound errors of its loaders, zero disables it.
+// It's usually shorter than the ttl of the loaded keys.
+func WithNegativeTTL[K comparable, V any](ttl time.Duration) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		if ttl < 0 {
+			return errors.New("negative ttl cannot be negative")
+		}
+
+		c.negativeTTL = ttl
+		return nil
+	}
+}
+
+// WithRefreshAfter sets the soft ttl of the keys, zero disables it.
+// Once it passes, GetOrLoad still returns the key's stale value but reloads it in the background,
+// only the key's own ttl makes the callers wait for the loader. Get does the same if the cache has a store.
+func WithRefreshAfter[K comparable, V any](refreshAfter time.Duration) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		if refreshAfter < 0 {
+			return errors.New("refresh after cannot be negative")
+		}
+
+		c.refreshAfter = refreshAfter
+		return nil
+	}
+}
+
+// WithCleanupInterval sets how often expired keys are removed in the background.
+// A zero interval disables the background cleanup.
+func WithCleanupInterval[K comparable, V any](interval time.Duration) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		if interval < 0 {
+			return errors.New("cleanup interval cannot be negative")
+		}
+
+		c.cleanupInterval = interval
+		return nil
+	}
+}
+
+// WithOnEvict sets a callback which is called with every value removed from the cache and the reason it was removed,
+// including the old values of overwritten keys.
+// It's called after the cache's locks are released, so it may use the cache, even the evicted key, or do I/O.
+func WithOnEvict[K comparable, V any](onEvict func(key K, val V, reason EvictionReason)) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		c.onEvict = onEvict
+		return nil
+	}
+}
+
+// WithClock sets the function used to get the current time, it defaults to time.Now.
+func WithClock[K comparable, V any](now func() time.Time) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		if now == nil {
+			return errors.New("clock cannot be nil")
+		}
+
+		c.now = now
+		return nil
+	}
+}
+
+// WithShards splits the cache into the given number of shards, each of them with its own lock, so
+// concurrent operations on keys of different shards don't wait for each other. It defaults to 1.
+// The capacity and max bytes are split evenly across the shards and each shard evicts on its own,
+// so values which cost more than a shard's share of max bytes are rejected with ErrTooLarge.
+// Keys of pointers, interfaces, or arrays and structs of floats require WithHasher to have more than one shard.
+func WithShards[K comparable, V any](shards uint64) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		if shards == 0 {
+			return errors.New("shards must be greater than 0")
+		}
+
+		c.numShards = shards
+		return nil
+	}
+}
+
+// WithHasher sets the function which maps the keys to the shards, keys which are == must be hashed alike.
+// Strings, integers and floats are hashed by default, other key types are hashed by their fmt representation,
+// which New rejects for the types whose keys which are == may be formatted differently.
+func WithHasher[K comparable, V any](hasher func(key K) uint64) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		if hasher == nil {
+			return errors.New("hasher cannot be nil")
+		}
+
+		c.hasher = hasher
+		return nil
+	}
+}
+
+// WithStore sets a backing store which the cache fronts.
+// Misses of Get are loaded from the store like GetOrLoad does, and writes are saved to the store
+// synchronously before the cache is touched, unless WithWriteBehind is given.
+func WithStore[K comparable, V any](store Store[K, V]) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		if store == nil {
+			return errors.New("store cannot be nil")
+		}
+
+		c.store = store
+		return nil
+	}
+}
+
+// WithLog records every Set, Delete and Flush of the cache in the append-only log, see OpenLog.
+// New replays the log, so the cache starts with the keys it had when the log was last written.
+// The log is compacted in the background once it doubles in size, or by CompactLog.
+// A write which can't be appended to the log returns its error, although it's already applied to the cache.
+func WithLog[K comparable, V any](log *Log[K, V]) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		if log == nil {
+			return errors.New("log cannot be nil")
+		}
+
+		c.log = log
+		return nil
+	}
+}
+
+// WithWriteBehind makes the writes to the store asynchronous, it requires WithStore.
+// Writes are applied to the cache right away and saved to the store as a batch every interval,
+// as soon as a key which isn't saved yet is evicted, on Sync and on Close.
+func WithWriteBehind[K comparable, V any](interval time.Duration) Option[K, V] {
+	return func(c *Cache[K, V]) error {
+		if interval <= 0 {
+			return errors.New("write behind interval must be greater than 0")
+		}
+
+		c.writeBehindInterval = interval
+		return nil
+	}
+}
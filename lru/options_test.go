@@ -0,0 +1,120 @@
+package lru
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	now := func() time.Time { return time.Unix(1000, 0) }
+	onEvict := func(key string, val int, reason EvictionReason) {}
+	sizer := func(key string, val int) uint64 { return 1 }
+
+	cache, err := New(
+		WithCapacity[string, int](10),
+		WithMaxBytes[string, int](100),
+		WithSizer(sizer),
+		WithDefaultTTL[string, int](time.Second),
+		WithCleanupInterval[string, int](0),
+		WithOnEvict(onEvict),
+		WithClock[string, int](now),
+	)
+	assert.NoError(t, err)
+	defer cache.Close()
+
+	assert.EqualValues(t, 10, cache.capacity)
+	assert.EqualValues(t, 100, cache.maxBytes)
+	assert.NotNil(t, cache.sizer)
+	assert.Equal(t, time.Second, cache.defaultTTL)
+	assert.Zero(t, cache.cleanupInterval)
+	assert.NotNil(t, cache.onEvict)
+	assert.Equal(t, time.Unix(1000, 0), cache.Now())
+}
+
+func TestOptionsErrors(t *testing.T) {
+	testcases := []struct {
+		name string
+		opt  Option[string, int]
+		err  string
+	}{
+		{name: "zero_capacity", opt: WithCapacity[string, int](0), err: "capacity must be greater than 0"},
+		{name: "negative_ttl", opt: WithDefaultTTL[string, int](-time.Second), err: "default ttl cannot be negative"},
+		{name: "negative_interval", opt: WithCleanupInterval[string, int](-time.Second), err: "cleanup interval cannot be negative"},
+		{name: "nil_clock", opt: WithClock[string, int](nil), err: "clock cannot be nil"},
+		{name: "zero_max_bytes", opt: WithMaxBytes[string, int](0), err: "max bytes must be greater than 0"},
+		{name: "nil_sizer", opt: WithSizer[string, int](nil), err: "sizer cannot be nil"},
+		{name: "max_bytes_without_sizer", opt: WithMaxBytes[string, int](10), err: "max bytes requires a sizer"},
+		{name: "negative_negative_ttl", opt: WithNegativeTTL[string, int](-time.Second), err: "negative ttl cannot be negative"},
+		{name: "negative_refresh_after", opt: WithRefreshAfter[string, int](-time.Second), err: "refresh after cannot be negative"},
+		{name: "nil_log", opt: WithLog[string, int](nil), err: "log cannot be nil"},
+		{name: "nil_store", opt: WithStore[string, int](nil), err: "store cannot be nil"},
+		{name: "zero_write_behind_interval", opt: WithWriteBehind[string, int](0), err: "write behind interval must be greater than 0"},
+		{name: "write_behind_without_store", opt: WithWriteBehind[string, int](time.Second), err: "write behind requires a store"},
+		{name: "zero_shards", opt: WithShards[string, int](0), err: "shards must be greater than 0"},
+		{name: "nil_hasher", opt: WithHasher[string, int](nil), err: "hasher cannot be nil"},
+		{name: "too_many_shards", opt: WithShards[string, int](DefaultCapacity + 1), err: "shards cannot be more than the capacity"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache, err := New(tc.opt)
+
+			assert.Nil(t, cache)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestWithDefaultTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t, WithDefaultTTL[string, int](time.Second), WithClock[string, int](clock.Now))
+
+	ctx := context.Background()
+
+	assert.NoError(t, cache.Set(ctx, "default", 1))
+	assert.NoError(t, cache.SetWithTTL(ctx, "forever", 2, 0))
+	assert.NoError(t, cache.SetWithTTL(ctx, "longer", 3, time.Minute))
+
+	clock.Advance(time.Second)
+
+	_, err := cache.Get(ctx, "default")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	v, err := cache.Get(ctx, "forever")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v)
+
+	v, err = cache.Get(ctx, "longer")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, v)
+}
+
+func TestWithOnEvict(t *testing.T) {
+	var evicted []string
+
+	var cache *Cache[string, int]
+	cache = newTestCache(t, WithCapacity[string, int](2), WithOnEvict(func(key string, val int, reason EvictionReason) {
+		if reason != EvictionReasonCapacity {
+			return
+		}
+		evicted = append(evicted, key)
+
+		// The lock is released before the callback is called.
+		_, err := cache.Get(context.Background(), key)
+		assert.ErrorIs(t, err, ErrNotFound)
+	}))
+
+	cache.set("first", 1, 0)
+	cache.set("second", 2, 0)
+	cache.set("first", 3, 0)
+
+	assert.Empty(t, evicted)
+
+	cache.set("third", 4, 0)
+	cache.set("fourth", 5, 0)
+
+	assert.Equal(t, []string{"second", "first"}, evicted)
+}
@@ -0,0 +1,179 @@
+package lru
+
+import (
+	"fmt"
+
+	linkedlist "github.com/MojtabaArezoomand/lru_cache/internal/linked_list"
+)
+
+type (
+	// Policy decides which key is evicted when the cache is full.
+	// Its methods are called while the cache's lock is held, so they don't need to be thread-safe.
+	Policy[K comparable] interface {
+		// Add records a key which is newly stored in the cache.
+		Add(key K)
+		// Access records a hit or an overwrite of a stored key.
+		Access(key K)
+		// Remove forgets a key which is deleted or expired.
+		Remove(key K)
+		// Evict forgets and returns the key which should be evicted next.
+		// It returns false if there is no key.
+		Evict() (K, bool)
+		// Keys returns the stored keys in eviction order, the next key to evict comes first.
+		Keys() []K
+	}
+
+	// SharedPolicy is a policy which records hits while only the cache's read lock is held,
+	// the cache looks up keys of such policies without serializing the reads.
+	// AccessShared may be called concurrently with itself, but never with the other methods.
+	SharedPolicy[K comparable] interface {
+		Policy[K]
+		// AccessShared records a hit of a stored key.
+		AccessShared(key K)
+	}
+
+	// PolicyName is the name of a built-in policy.
+	PolicyName string
+
+	// listPolicy is a policy which keeps the keys in a single list.
+	listPolicy[K comparable] struct {
+		list  *linkedlist.DoublyLinkedList[K, struct{}]
+		nodes map[K]*linkedlist.Node[K, struct{}]
+
+		// moveOnAccess moves accessed keys to the back of the list.
+		moveOnAccess bool
+		// evictBack evicts from the back of the list instead of the front.
+		evictBack bool
+	}
+)
+
+// Built-in policies.
+const (
+	PolicyLRU    PolicyName = "lru"
+	PolicyLFU    PolicyName = "lfu"
+	PolicyFIFO   PolicyName = "fifo"
+	PolicyRandom PolicyName = "random"
+	PolicyMRU    PolicyName = "mru"
+	// PolicyWTinyLFU is the W-TinyLFU policy, see NewWTinyLFU.
+	PolicyWTinyLFU PolicyName = "wtinylfu"
+	// PolicyARC is the Adaptive Replacement Cache policy, see NewARC.
+	PolicyARC PolicyName = "arc"
+	// PolicyTwoQ is the 2Q policy, see NewTwoQ.
+	PolicyTwoQ PolicyName = "2q"
+	// PolicySLRU is the Segmented LRU policy with DefaultProtectedRatio, see NewSLRU.
+	PolicySLRU PolicyName = "slru"
+	// PolicyCLOCK is the CLOCK policy, see NewCLOCK.
+	PolicyCLOCK PolicyName = "clock"
+	// PolicySIEVE is the SIEVE policy, see NewSIEVE.
+	PolicySIEVE PolicyName = "sieve"
+)
+
+// newPolicy returns a new built-in policy for a cache with the given capacity.
+func newPolicy[K comparable](name PolicyName, capacity uint64) (Policy[K], error) {
+	switch name {
+	case PolicyLRU:
+		return NewLRU[K](), nil
+	case PolicyLFU:
+		return NewLFU[K](), nil
+	case PolicyFIFO:
+		return NewFIFO[K](), nil
+	case PolicyRandom:
+		return NewRandom[K](), nil
+	case PolicyMRU:
+		return NewMRU[K](), nil
+	case PolicyWTinyLFU:
+		return NewWTinyLFU[K](capacity), nil
+	case PolicyARC:
+		return NewARC[K](capacity), nil
+	case PolicyTwoQ:
+		return NewTwoQ[K](capacity), nil
+	case PolicySLRU:
+		return NewSLRU[K](capacity, DefaultProtectedRatio), nil
+	case PolicyCLOCK:
+		return NewCLOCK[K](), nil
+	case PolicySIEVE:
+		return NewSIEVE[K](), nil
+	default:
+		return nil, fmt.Errorf("unknown policy %q", name)
+	}
+}
+
+// NewLRU returns a policy which evicts the least recently used key.
+func NewLRU[K comparable]() Policy[K] {
+	return newListPolicy[K](true, false)
+}
+
+// NewMRU returns a policy which evicts the most recently used key.
+func NewMRU[K comparable]() Policy[K] {
+	return newListPolicy[K](true, true)
+}
+
+// NewFIFO returns a policy which evicts the oldest key, regardless of how it's used.
+func NewFIFO[K comparable]() Policy[K] {
+	return newListPolicy[K](false, false)
+}
+
+// newListPolicy returns a new list policy.
+func newListPolicy[K comparable](moveOnAccess, evictBack bool) *listPolicy[K] {
+	return &listPolicy[K]{
+		list:         linkedlist.NewDoublyLinkedList[K, struct{}](),
+		nodes:        make(map[K]*linkedlist.Node[K, struct{}]),
+		moveOnAccess: moveOnAccess,
+		evictBack:    evictBack,
+	}
+}
+
+// Add implements Policy interface.
+func (p *listPolicy[K]) Add(key K) {
+	p.nodes[key] = p.list.AddToBack(key, struct{}{})
+}
+
+// Access implements Policy interface.
+func (p *listPolicy[K]) Access(key K) {
+	if node, ok := p.nodes[key]; ok && p.moveOnAccess {
+		p.list.MoveToBack(node)
+	}
+}
+
+// Remove implements Policy interface.
+func (p *listPolicy[K]) Remove(key K) {
+	if node, ok := p.nodes[key]; ok {
+		p.list.Remove(node)
+		delete(p.nodes, key)
+	}
+}
+
+// Evict implements Policy interface.
+func (p *listPolicy[K]) Evict() (K, bool) {
+	if p.list.Size() == 0 {
+		var zero K
+		return zero, false
+	}
+
+	node := p.list.Head()
+	if p.evictBack {
+		node = p.list.Tail()
+	}
+
+	p.list.Remove(node)
+	delete(p.nodes, node.GetKey())
+
+	return node.GetKey(), true
+}
+
+// Keys implements Policy interface.
+func (p *listPolicy[K]) Keys() []K {
+	keys := make([]K, 0, p.list.Size())
+
+	if p.evictBack {
+		for node := p.list.Tail(); node != nil; node = node.Prev() {
+			keys = append(keys, node.GetKey())
+		}
+	} else {
+		for node := p.list.Head(); node != nil; node = node.Next() {
+			keys = append(keys, node.GetKey())
+		}
+	}
+
+	return keys
+}
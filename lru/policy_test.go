@@ -0,0 +1,148 @@
+package lru
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// evictAll evicts every key of the policy in order.
+func evictAll[K comparable](p Policy[K]) []K {
+	var keys []K
+	for {
+		key, ok := p.Evict()
+		if !ok {
+			return keys
+		}
+		keys = append(keys, key)
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	for _, name := range []PolicyName{PolicyLRU, PolicyLFU, PolicyFIFO, PolicyRandom, PolicyMRU, PolicyWTinyLFU, PolicyARC, PolicyTwoQ, PolicySLRU, PolicyCLOCK, PolicySIEVE} {
+		p, err := newPolicy[string](name, 10)
+		assert.NoError(t, err)
+		assert.NotNil(t, p)
+	}
+
+	_, err := newPolicy[string]("unknown", 10)
+	assert.EqualError(t, err, `unknown policy "unknown"`)
+}
+
+func TestLRU(t *testing.T) {
+	p := NewLRU[string]()
+
+	_, ok := p.Evict()
+	assert.False(t, ok)
+
+	p.Add("1")
+	p.Add("2")
+	p.Add("3")
+	p.Add("4")
+
+	p.Access("1")
+	p.Access("not_found")
+	p.Remove("3")
+	p.Remove("not_found")
+
+	assert.Equal(t, []string{"2", "4", "1"}, p.Keys())
+	assert.Equal(t, []string{"2", "4", "1"}, evictAll(p))
+	assert.Empty(t, p.Keys())
+}
+
+func TestMRU(t *testing.T) {
+	p := NewMRU[string]()
+
+	p.Add("1")
+	p.Add("2")
+	p.Add("3")
+	p.Add("4")
+
+	p.Access("1")
+	p.Remove("3")
+
+	assert.Equal(t, []string{"1", "4", "2"}, p.Keys())
+	assert.Equal(t, []string{"1", "4", "2"}, evictAll(p))
+}
+
+func TestFIFO(t *testing.T) {
+	p := NewFIFO[string]()
+
+	p.Add("1")
+	p.Add("2")
+	p.Add("3")
+
+	p.Access("1")
+	p.Remove("2")
+
+	assert.Equal(t, []string{"1", "3"}, p.Keys())
+	assert.Equal(t, []string{"1", "3"}, evictAll(p))
+}
+
+func TestWithPolicy(t *testing.T) {
+	testcases := []struct {
+		name    string
+		policy  PolicyName
+		access  string
+		evicted string
+	}{
+		{name: "lru", policy: PolicyLRU, access: "first", evicted: "second"},
+		{name: "fifo", policy: PolicyFIFO, access: "first", evicted: "first"},
+		{name: "mru", policy: PolicyMRU, access: "first", evicted: "first"},
+		{name: "lfu", policy: PolicyLFU, access: "second", evicted: "first"},
+		{name: "wtinylfu", policy: PolicyWTinyLFU, access: "second", evicted: "first"},
+		{name: "arc", policy: PolicyARC, access: "second", evicted: "first"},
+		{name: "2q", policy: PolicyTwoQ, access: "second", evicted: "first"},
+		{name: "slru", policy: PolicySLRU, access: "first", evicted: "second"},
+		{name: "clock", policy: PolicyCLOCK, access: "first", evicted: "second"},
+		{name: "sieve", policy: PolicySIEVE, access: "first", evicted: "second"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := newTestCache(t, WithCapacity[string, int](2), WithPolicy[string, int](tc.policy))
+
+			ctx := context.Background()
+
+			assert.NoError(t, cache.Set(ctx, "first", 1))
+			assert.NoError(t, cache.Set(ctx, "second", 2))
+
+			_, err := cache.Get(ctx, tc.access)
+			assert.NoError(t, err)
+
+			assert.NoError(t, cache.Set(ctx, "third", 3))
+
+			// The new key is never the one which is evicted.
+			_, err = cache.Get(ctx, "third")
+			assert.NoError(t, err)
+
+			_, err = cache.Get(ctx, tc.evicted)
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			assert.Len(t, cache.shards[0].storage, 2)
+		})
+	}
+
+	_, err := New(WithPolicy[string, int]("unknown"))
+	assert.EqualError(t, err, `unknown policy "unknown"`)
+}
+
+func TestWithPolicyFunc(t *testing.T) {
+	var capacities []uint64
+
+	cache := newTestCache(t, WithCapacity[string, int](5), WithPolicyFunc[string, int](func(capacity uint64) Policy[string] {
+		capacities = append(capacities, capacity)
+		return NewFIFO[string]()
+	}))
+
+	cache.set("key", 1, 0)
+	cache.flush()
+
+	// The policy is created again on flush.
+	assert.Equal(t, []uint64{5, 5}, capacities)
+	assert.Empty(t, cache.shards[0].policy.Keys())
+
+	_, err := New(WithPolicyFunc[string, int](nil))
+	assert.EqualError(t, err, "policy func cannot be nil")
+}
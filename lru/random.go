@@ -0,0 +1,74 @@
+package lru
+
+import (
+	"math/rand"
+	"time"
+)
+
+// random is a policy which evicts a random key.
+// Keys are kept in a slice so a random one can be picked and removed in O(1).
+type random[K comparable] struct {
+	keys    []K
+	indexes map[K]int
+	rand    *rand.Rand
+}
+
+// NewRandom returns a policy which evicts a random key.
+func NewRandom[K comparable]() Policy[K] {
+	return newRandom[K](rand.New(rand.NewSource(time.Now().UnixNano())))
+}
+
+// newRandom returns a new random policy which uses the given source of randomness.
+func newRandom[K comparable](r *rand.Rand) *random[K] {
+	return &random[K]{indexes: make(map[K]int), rand: r}
+}
+
+// Add implements Policy interface.
+func (p *random[K]) Add(key K) {
+	p.indexes[key] = len(p.keys)
+	p.keys = append(p.keys, key)
+}
+
+// Access implements Policy interface.
+func (p *random[K]) Access(key K) {}
+
+// Remove implements Policy interface.
+func (p *random[K]) Remove(key K) {
+	i, ok := p.indexes[key]
+	if !ok {
+		return
+	}
+
+	// Moves the last key to the removed key's place.
+	last := len(p.keys) - 1
+	p.keys[i] = p.keys[last]
+	p.indexes[p.keys[i]] = i
+
+	var zero K
+	p.keys[last] = zero
+	p.keys = p.keys[:last]
+
+	delete(p.indexes, key)
+}
+
+// Evict implements Policy interface.
+func (p *random[K]) Evict() (K, bool) {
+	if len(p.keys) == 0 {
+		var zero K
+		return zero, false
+	}
+
+	key := p.keys[p.rand.Intn(len(p.keys))]
+	p.Remove(key)
+
+	return key, true
+}
+
+// Keys implements Policy interface.
+// There is no eviction order, so the keys are returned in their internal order.
+func (p *random[K]) Keys() []K {
+	keys := make([]K, len(p.keys))
+	copy(keys, p.keys)
+
+	return keys
+}
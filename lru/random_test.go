@@ -0,0 +1,37 @@
+package lru
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandom(t *testing.T) {
+	p := newRandom[string](rand.New(rand.NewSource(1)))
+
+	_, ok := p.Evict()
+	assert.False(t, ok)
+
+	p.Add("1")
+	p.Add("2")
+	p.Add("3")
+	p.Add("4")
+
+	p.Access("1")
+
+	// The last key takes the removed key's place.
+	p.Remove("2")
+	p.Remove("not_found")
+
+	assert.Equal(t, []string{"1", "4", "3"}, p.Keys())
+	assert.Equal(t, map[string]int{"1": 0, "4": 1, "3": 2}, p.indexes)
+
+	evicted := evictAll[string](p)
+
+	assert.ElementsMatch(t, []string{"1", "3", "4"}, evicted)
+	assert.Empty(t, p.keys)
+	assert.Empty(t, p.indexes)
+
+	assert.NotNil(t, NewRandom[string]())
+}
@@ -0,0 +1,96 @@
+package lru
+
+import (
+	linkedlist "github.com/MojtabaArezoomand/lru_cache/internal/linked_list"
+)
+
+type (
+	// segment is one of the lists of a segmented policy.
+	segment uint8
+
+	// segments keeps the keys of a policy in several lists, each key lives in exactly one of them.
+	// Keys are added to the back of the lists, so the front of a list is its least recently used key.
+	segments[K comparable] struct {
+		lists []*linkedlist.DoublyLinkedList[K, segment]
+		nodes map[K]*linkedlist.Node[K, segment]
+	}
+)
+
+// newSegments returns n empty segments.
+func newSegments[K comparable](n int) segments[K] {
+	lists := make([]*linkedlist.DoublyLinkedList[K, segment], n)
+	for i := range lists {
+		lists[i] = linkedlist.NewDoublyLinkedList[K, segment]()
+	}
+
+	return segments[K]{lists: lists, nodes: make(map[K]*linkedlist.Node[K, segment])}
+}
+
+// push adds the key to the back of the segment.
+func (s *segments[K]) push(key K, seg segment) {
+	s.nodes[key] = s.lists[seg].AddToBack(key, seg)
+}
+
+// move moves the key's node to the back of the segment, which may be its current segment.
+func (s *segments[K]) move(node *linkedlist.Node[K, segment], seg segment) {
+	if node.GetVal() == seg {
+		s.lists[seg].MoveToBack(node)
+		return
+	}
+
+	s.lists[node.GetVal()].Remove(node)
+	s.push(node.GetKey(), seg)
+}
+
+// remove removes the key from its segment, it returns false if the key doesn't exist.
+func (s *segments[K]) remove(key K) bool {
+	node, ok := s.nodes[key]
+	if !ok {
+		return false
+	}
+
+	s.lists[node.GetVal()].Remove(node)
+	delete(s.nodes, key)
+
+	return true
+}
+
+// pop removes the node and returns its key, it returns false if the node is nil.
+func (s *segments[K]) pop(node *linkedlist.Node[K, segment]) (K, bool) {
+	if node == nil {
+		var zero K
+		return zero, false
+	}
+
+	key := node.GetKey()
+	s.remove(key)
+
+	return key, true
+}
+
+// head returns the front of the segment, it's nil if the segment is empty.
+func (s *segments[K]) head(seg segment) *linkedlist.Node[K, segment] {
+	return s.lists[seg].Head()
+}
+
+// size returns the number of keys in the segment.
+func (s *segments[K]) size(seg segment) uint64 {
+	return s.lists[seg].Size()
+}
+
+// keys returns the keys of the given segments in order, from the front to the back of each one.
+func (s *segments[K]) keys(segs ...segment) []K {
+	var n uint64
+	for _, seg := range segs {
+		n += s.size(seg)
+	}
+
+	keys := make([]K, 0, n)
+	for _, seg := range segs {
+		for node := s.head(seg); node != nil; node = node.Next() {
+			keys = append(keys, node.GetKey())
+		}
+	}
+
+	return keys
+}
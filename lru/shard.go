@@ -0,0 +1,360 @@
+package lru
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shard is an independent part of the cache, with its own lock, storage and policy.
+// The capacity and max bytes of the cache are split across its shards.
+type shard[K comparable, V any] struct {
+	cache *Cache[K, V]
+
+	m       sync.RWMutex
+	storage map[K]*entry[V]
+	policy  Policy[K]
+
+	// shared is true if the policy is a SharedPolicy, so hits are served under the read lock.
+	// It's read without the lock, so it's set once, flush replaces the policy with one of the same type.
+	shared bool
+
+	capacity uint64
+	// maxBytes is the maximum total cost of the shard's entries, zero means unlimited.
+	maxBytes uint64
+	bytes    uint64
+}
+
+// newShard returns a new empty shard of the cache.
+func newShard[K comparable, V any](c *Cache[K, V], capacity, maxBytes uint64) (*shard[K, V], error) {
+	policy, err := c.policyFunc(capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	s := shard[K, V]{
+		cache:    c,
+		storage:  make(map[K]*entry[V]),
+		policy:   policy,
+		capacity: capacity,
+		maxBytes: maxBytes,
+	}
+	_, s.shared = policy.(SharedPolicy[K])
+
+	return &s, nil
+}
+
+// split returns the i-th of n shares of total, the remainder is spread over the first shares.
+func split(total, n uint64, i int) uint64 {
+	share := total / n
+	if uint64(i) < total%n {
+		share++
+	}
+	return share
+}
+
+// get fetches the key's entry from storage.
+// Expired keys are removed and reported as not found.
+func (s *shard[K, V]) get(key K) (entry[V], error) {
+	c := s.cache
+
+	if s.shared {
+		if e, ok, err := s.getShared(key); ok {
+			return e, err
+		}
+	}
+
+	var evictions []eviction[K, V]
+	defer func() { c.notify(evictions) }()
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	e, ok := s.storage[key]
+	if !ok {
+		inc(&c.counters.misses)
+		return entry[V]{}, ErrNotFound
+	}
+
+	if e.expired(c.now()) {
+		s.remove(key, e)
+		evictions = c.evicted(evictions, key, e.val, EvictionReasonExpired)
+		inc(&c.counters.misses)
+		inc(&c.counters.expirations)
+		return entry[V]{}, ErrNotFound
+	}
+
+	s.policy.Access(key)
+	inc(&c.counters.hits)
+	return *e, nil
+}
+
+// getShared looks up the key under the read lock if the policy is a SharedPolicy.
+// It returns false if the key must be looked up under the write lock instead,
+// because the policy isn't shared or the key is expired and must be removed.
+func (s *shard[K, V]) getShared(key K) (entry[V], bool, error) {
+	c := s.cache
+
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	policy, ok := s.policy.(SharedPolicy[K])
+	if !ok {
+		return entry[V]{}, false, nil
+	}
+
+	e, ok := s.storage[key]
+	if !ok {
+		inc(&c.counters.misses)
+		return entry[V]{}, true, ErrNotFound
+	}
+
+	if e.expired(c.now()) {
+		return entry[V]{}, false, nil
+	}
+
+	policy.AccessShared(key)
+	inc(&c.counters.hits)
+	return *e, true, nil
+}
+
+// peek returns the key's entry if it's stored and not expired, without recording a hit or a miss.
+func (s *shard[K, V]) peek(key K) (entry[V], bool) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	e, ok := s.storage[key]
+	if !ok || e.expired(s.cache.now()) {
+		return entry[V]{}, false
+	}
+
+	return *e, true
+}
+
+// set sets or overwrites the key's entry to storage, it returns the evictions to be notified by the caller.
+// Keys chosen by the policy are evicted until both the capacity and max bytes are respected.
+func (s *shard[K, V]) set(key K, e *entry[V], ttl time.Duration) ([]eviction[K, V], error) {
+	_, evictions, err := s.setIf(key, e, ttl, nil)
+	return evictions, err
+}
+
+// setIf sets the key's entry like set if the check of the key's current entry passes, see Cache.setChecked.
+func (s *shard[K, V]) setIf(key K, e *entry[V], ttl time.Duration, check func(old *entry[V]) error) (bool, []eviction[K, V], error) {
+	c := s.cache
+
+	if !s.fits(e) {
+		return false, nil, ErrTooLarge
+	}
+
+	var evictions []eviction[K, V]
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if check != nil {
+		old, ok := s.storage[key]
+		if !ok || old.expired(c.now()) {
+			old = nil
+		}
+
+		if ok, err := runCheck(check, old); !ok || err != nil {
+			return false, nil, err
+		}
+	}
+
+	e.version = atomic.AddUint64(&c.counters.versions, 1)
+
+	if ttl > 0 {
+		e.expiresAt = c.now().Add(ttl)
+	}
+	if c.refreshAfter > 0 {
+		e.staleAt = c.now().Add(c.refreshAfter)
+	}
+
+	if old, ok := s.storage[key]; ok {
+		if old.expired(c.now()) {
+			evictions = c.evicted(evictions, key, old.val, EvictionReasonExpired)
+			inc(&c.counters.expirations)
+		} else {
+			evictions = c.evicted(evictions, key, old.val, EvictionReasonReplaced)
+			inc(&c.counters.overwrites)
+		}
+
+		s.bytes -= old.cost
+
+		if s.maxBytes == 0 || s.bytes+e.cost <= s.maxBytes {
+			s.policy.Access(key)
+		} else {
+			// The key is taken out while making room for its new value, so it's never evicted itself.
+			s.policy.Remove(key)
+			delete(s.storage, key)
+			evictions = s.evict(evictions, e.cost)
+			s.policy.Add(key)
+		}
+	} else {
+		evictions = s.evict(evictions, e.cost)
+		s.policy.Add(key)
+	}
+
+	s.storage[key] = e
+	s.bytes += e.cost
+	inc(&c.counters.sets)
+
+	return true, evictions, nil
+}
+
+// expire changes the key's expiration time, a zero or negative ttl means the key never expires.
+// It returns the eviction of the key if it's already expired, to be notified by the caller.
+func (s *shard[K, V]) expire(key K, ttl time.Duration) (entry[V], []eviction[K, V], error) {
+	c := s.cache
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	e, ok := s.storage[key]
+	if !ok {
+		return entry[V]{}, nil, ErrNotFound
+	}
+
+	if e.expired(c.now()) {
+		s.remove(key, e)
+		inc(&c.counters.expirations)
+		return entry[V]{}, c.evicted(nil, key, e.val, EvictionReasonExpired), ErrNotFound
+	}
+
+	e.expiresAt = time.Time{}
+	if ttl > 0 {
+		e.expiresAt = c.now().Add(ttl)
+	}
+
+	return *e, nil, nil
+}
+
+// fits reports whether the entry's cost isn't larger than the shard's max bytes.
+func (s *shard[K, V]) fits(e *entry[V]) bool {
+	return s.maxBytes == 0 || e.cost <= s.maxBytes
+}
+
+// evict evicts the keys chosen by the policy until a new key-value with the given cost fits in.
+// Caller must hold the lock.
+func (s *shard[K, V]) evict(evictions []eviction[K, V], cost uint64) []eviction[K, V] {
+	c := s.cache
+	now := c.now()
+
+	for uint64(len(s.storage)) >= s.capacity || (s.maxBytes > 0 && s.bytes+cost > s.maxBytes) {
+		key, ok := s.policy.Evict()
+		if !ok {
+			break
+		}
+
+		e := s.storage[key]
+		delete(s.storage, key)
+		s.bytes -= e.cost
+
+		if e.expired(now) {
+			evictions = c.evicted(evictions, key, e.val, EvictionReasonExpired)
+			inc(&c.counters.expirations)
+		} else {
+			evictions = c.evicted(evictions, key, e.val, EvictionReasonCapacity)
+			inc(&c.counters.evictions)
+		}
+
+		if c.behind != nil {
+			c.behind.evicted(key)
+		}
+	}
+
+	return evictions
+}
+
+// delete removes the key from storage, it returns the key's eviction to be notified by the caller.
+func (s *shard[K, V]) delete(key K) ([]eviction[K, V], error) {
+	_, evictions, err := s.deleteIf(key, nil)
+	return evictions, err
+}
+
+// deleteIf deletes the key like delete if the check of the key's current entry passes, see Cache.setChecked.
+func (s *shard[K, V]) deleteIf(key K, check func(old *entry[V]) error) (bool, []eviction[K, V], error) {
+	c := s.cache
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	e, ok := s.storage[key]
+	if !ok {
+		return false, nil, ErrNotFound
+	}
+
+	if check != nil {
+		old := e
+		if e.expired(c.now()) {
+			old = nil
+		}
+
+		if ok, err := runCheck(check, old); !ok || err != nil {
+			return false, nil, err
+		}
+	}
+
+	s.remove(key, e)
+
+	if e.expired(c.now()) {
+		inc(&c.counters.expirations)
+		return true, c.evicted(nil, key, e.val, EvictionReasonExpired), ErrNotFound
+	}
+
+	inc(&c.counters.deletes)
+	return true, c.evicted(nil, key, e.val, EvictionReasonDeleted), nil
+}
+
+// remove removes the key from both the policy and storage.
+// Caller must hold the lock.
+func (s *shard[K, V]) remove(key K, e *entry[V]) {
+	s.policy.Remove(key)
+	delete(s.storage, key)
+	s.bytes -= e.cost
+}
+
+// deleteExpired removes all of the expired keys.
+func (s *shard[K, V]) deleteExpired() {
+	c := s.cache
+
+	var evictions []eviction[K, V]
+	defer func() { c.notify(evictions) }()
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	now := c.now()
+	for key, e := range s.storage {
+		if e.expired(now) {
+			s.remove(key, e)
+			evictions = c.evicted(evictions, key, e.val, EvictionReasonExpired)
+			inc(&c.counters.expirations)
+		}
+	}
+}
+
+// flush resets the shard, it returns the evictions of the removed keys to be notified by the caller.
+func (s *shard[K, V]) flush() []eviction[K, V] {
+	c := s.cache
+
+	var evictions []eviction[K, V]
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if c.onEvict != nil {
+		evictions = make([]eviction[K, V], 0, len(s.storage))
+		for _, key := range s.policy.Keys() {
+			evictions = c.evicted(evictions, key, s.storage[key].val, EvictionReasonFlushed)
+		}
+	}
+
+	// The policy was already created once by New, so it can't fail here.
+	s.policy, _ = c.policyFunc(s.capacity)
+	s.storage = make(map[K]*entry[V])
+	s.bytes = 0
+
+	return evictions
+}
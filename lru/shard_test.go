@@ -0,0 +1,117 @@
+package lru
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	assert.Equal(t, []uint64{4, 3, 3}, []uint64{split(10, 3, 0), split(10, 3, 1), split(10, 3, 2)})
+	assert.Equal(t, []uint64{0, 0}, []uint64{split(0, 2, 0), split(0, 2, 1)})
+}
+
+func TestShards(t *testing.T) {
+	// Even keys go to the first shard and odd keys to the second one.
+	cache := newTestCache(t,
+		WithCapacity[string, int](5),
+		WithShards[string, int](2),
+		WithHasher[string, int](func(key string) uint64 {
+			n, _ := strconv.ParseUint(key, 10, 64)
+			return n
+		}),
+	)
+
+	assert.Len(t, cache.shards, 2)
+	assert.Equal(t, uint64(3), cache.shards[0].capacity)
+	assert.Equal(t, uint64(2), cache.shards[1].capacity)
+
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, cache.Set(ctx, strconv.Itoa(i), i))
+	}
+
+	// Each shard evicts on its own.
+	assert.Equal(t, []string{"4", "6", "8"}, cache.shards[0].policy.Keys())
+	assert.Equal(t, []string{"7", "9"}, cache.shards[1].policy.Keys())
+
+	val, err := cache.Get(ctx, "7")
+	assert.NoError(t, err)
+	assert.Equal(t, 7, val)
+
+	_, err = cache.Get(ctx, "5")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, cache.Delete(ctx, "8"))
+	assert.ErrorIs(t, cache.Delete(ctx, "8"), ErrNotFound)
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(4), stats.Size)
+	assert.Equal(t, uint64(5), stats.Evictions)
+
+	assert.NoError(t, cache.Flush(ctx))
+	assert.Empty(t, cache.shards[0].storage)
+	assert.Empty(t, cache.shards[1].storage)
+}
+
+func TestShardsMaxBytes(t *testing.T) {
+	sizer := func(key string, val int) uint64 { return uint64(val) }
+
+	cache := newTestCache(t,
+		WithCapacity[string, int](10),
+		WithShards[string, int](2),
+		WithMaxBytes[string, int](10),
+		WithSizer(sizer),
+	)
+
+	assert.Equal(t, uint64(5), cache.shards[0].maxBytes)
+	assert.Equal(t, uint64(5), cache.shards[1].maxBytes)
+
+	ctx := context.Background()
+
+	// Values must fit in a shard's share of max bytes.
+	assert.NoError(t, cache.Set(ctx, "fits", 5))
+	assert.ErrorIs(t, cache.Set(ctx, "too_large", 6), ErrTooLarge)
+
+	_, err := New(WithShards[string, int](11), WithCapacity[string, int](20), WithMaxBytes[string, int](10), WithSizer(sizer))
+	assert.EqualError(t, err, "shards cannot be more than the max bytes")
+}
+
+func BenchmarkShards(b *testing.B) {
+	for _, shards := range []uint64{1, 4, 16, 64} {
+		b.Run(strconv.FormatUint(shards, 10), func(b *testing.B) {
+			cache, err := New(WithCapacity[string, int](4096), WithShards[string, int](shards), WithCleanupInterval[string, int](0))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer cache.Close()
+
+			ctx := context.Background()
+
+			keys := make([]string, 8192)
+			for i := range keys {
+				keys[i] = strconv.Itoa(i)
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				// Goroutines start at different keys, so they don't contend on the same shard.
+				i := rand.Intn(len(keys))
+				for pb.Next() {
+					key := keys[i%len(keys)]
+					// One write for every three reads.
+					if i%4 == 0 {
+						_ = cache.Set(ctx, key, i)
+					} else {
+						_, _ = cache.Get(ctx, key)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
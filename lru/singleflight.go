@@ -0,0 +1,94 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// errLoaderPanicked is returned to the callers waiting for a loader which panicked.
+var errLoaderPanicked = errors.New("loader panicked")
+
+type (
+	// group deduplicates concurrent calls for the same key, so only one of them runs at a time.
+	group[K comparable, V any] struct {
+		m     sync.Mutex
+		calls map[K]*call[V]
+	}
+
+	// call is an in-flight or completed call of a group.
+	call[V any] struct {
+		done chan struct{}
+		val  V
+		err  error
+	}
+)
+
+// do runs fn for the key unless a call for the key is already in flight, in which case it waits for its result.
+// It returns true if the result is shared from another caller's call.
+// Waiting stops once ctx is done, but fn keeps running for the caller which started it.
+func (g *group[K, V]) do(ctx context.Context, key K, fn func() (V, error)) (V, bool, error) {
+	c, started := g.start(key)
+	if !started {
+		select {
+		case <-c.done:
+			return c.val, true, c.err
+		case <-ctx.Done():
+			var zero V
+			return zero, true, ctx.Err()
+		}
+	}
+
+	g.run(key, c, fn)
+	return c.val, false, c.err
+}
+
+// doAsync runs fn for the key in a new goroutine unless a call for the key is already in flight.
+// It returns false if a call is already in flight, the callers of do wait for the call either way.
+func (g *group[K, V]) doAsync(key K, fn func() (V, error)) bool {
+	c, started := g.start(key)
+	if !started {
+		return false
+	}
+
+	go g.run(key, c, fn)
+	return true
+}
+
+// start registers a new call for the key, or returns false along with the call which is already in flight.
+func (g *group[K, V]) start(key K) (*call[V], bool) {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+
+	if c, ok := g.calls[key]; ok {
+		return c, false
+	}
+
+	c := &call[V]{done: make(chan struct{})}
+	g.calls[key] = c
+	return c, true
+}
+
+// run runs fn as the key's call and finishes the call.
+func (g *group[K, V]) run(key K, c *call[V], fn func() (V, error)) {
+	// The call is finished even if fn panics, so the waiters don't block forever.
+	finished := false
+	defer func() {
+		if !finished {
+			c.err = errLoaderPanicked
+		}
+
+		g.m.Lock()
+		delete(g.calls, key)
+		g.m.Unlock()
+
+		close(c.done)
+	}()
+
+	c.val, c.err = fn()
+	finished = true
+}
@@ -0,0 +1,104 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	var g group[string, int]
+
+	ctx := context.Background()
+
+	val, shared, err := g.do(ctx, "key", func() (int, error) { return 1, nil })
+	assert.NoError(t, err)
+	assert.False(t, shared)
+	assert.Equal(t, 1, val)
+
+	// Finished calls are forgotten.
+	val, _, err = g.do(ctx, "key", func() (int, error) { return 2, errors.New("failed") })
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, 2, val)
+	assert.Empty(t, g.calls)
+}
+
+func TestGroupDeduplicates(t *testing.T) {
+	var g group[string, int]
+
+	started, release := make(chan struct{}), make(chan struct{})
+	go g.do(context.Background(), "key", func() (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	// A waiter whose context is done stops waiting.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, shared, err := g.do(ctx, "key", func() (int, error) { return 2, nil })
+	assert.True(t, shared)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+	val, shared, err := g.do(context.Background(), "key", func() (int, error) { return 2, nil })
+	assert.NoError(t, err)
+	assert.True(t, shared)
+	assert.Equal(t, 1, val)
+}
+
+func TestGroupPanic(t *testing.T) {
+	var g group[string, int]
+
+	started, release := make(chan struct{}), make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() { assert.Equal(t, "boom", recover()) }()
+
+		g.do(context.Background(), "key", func() (int, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+	// The waiters don't block forever.
+	_, _, err := g.do(context.Background(), "key", func() (int, error) { return 1, nil })
+	assert.ErrorIs(t, err, errLoaderPanicked)
+
+	wg.Wait()
+	assert.Empty(t, g.calls)
+}
+
+func TestGroupAsync(t *testing.T) {
+	var g group[string, int]
+
+	release := make(chan struct{})
+	assert.True(t, g.doAsync("key", func() (int, error) {
+		<-release
+		return 1, nil
+	}))
+
+	// Only one call runs at a time.
+	assert.False(t, g.doAsync("key", func() (int, error) { return 2, nil }))
+
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+	val, shared, err := g.do(context.Background(), "key", func() (int, error) { return 3, nil })
+	assert.NoError(t, err)
+	assert.True(t, shared)
+	assert.Equal(t, 1, val)
+}
@@ -0,0 +1,76 @@
+package lru
+
+// sketchDepth is the number of rows of the count-min sketch.
+const sketchDepth = 4
+
+// sketchMaxCount is the value the counters saturate at.
+const sketchMaxCount = 15
+
+// countMinSketch estimates the access frequency of keys in a fixed amount of memory.
+// Its counters are halved once the number of increments reaches the sample size,
+// so the frequencies of keys which aren't accessed anymore age out.
+type countMinSketch struct {
+	rows       [sketchDepth][]uint8
+	mask       uint64
+	additions  uint64
+	sampleSize uint64
+}
+
+// newCountMinSketch returns a sketch sized for the given number of keys.
+// Each row has four counters per key to keep the collisions low, and the counters are aged
+// after about ten increments per key.
+func newCountMinSketch(size uint64) *countMinSketch {
+	width := uint64(16)
+	for width < 4*size {
+		width <<= 1
+	}
+
+	s := countMinSketch{mask: width - 1, sampleSize: 10 * width / 4}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+
+	return &s
+}
+
+// index returns the counter's index of the hash in the row.
+func (s *countMinSketch) index(hash uint64, row int) uint64 {
+	h1, h2 := hash&0xffffffff, hash>>32
+	return (h1 + uint64(row)*h2) & s.mask
+}
+
+// Increment increments the hash's counters and ages the sketch once the sample size is reached.
+func (s *countMinSketch) Increment(hash uint64) {
+	for i := range s.rows {
+		idx := s.index(hash, i)
+		if s.rows[i][idx] < sketchMaxCount {
+			s.rows[i][idx]++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+// Estimate returns the estimated frequency of the hash.
+func (s *countMinSketch) Estimate(hash uint64) uint8 {
+	min := uint8(sketchMaxCount)
+	for i := range s.rows {
+		if c := s.rows[i][s.index(hash, i)]; c < min {
+			min = c
+		}
+	}
+	return min
+}
+
+// reset halves all of the counters.
+func (s *countMinSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
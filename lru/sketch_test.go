@@ -0,0 +1,39 @@
+package lru
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCountMinSketch(t *testing.T) {
+	// The width is a power of two which is at least 16.
+	assert.Equal(t, uint64(15), newCountMinSketch(0).mask)
+	assert.Equal(t, uint64(511), newCountMinSketch(100).mask)
+	assert.Equal(t, uint64(1280), newCountMinSketch(100).sampleSize)
+}
+
+func TestCountMinSketch(t *testing.T) {
+	s := newCountMinSketch(4)
+	hot, cold := hashKey("hot"), hashKey("cold")
+
+	assert.Equal(t, uint8(0), s.Estimate(hot))
+
+	for i := 0; i < 10; i++ {
+		s.Increment(hot)
+	}
+	assert.Equal(t, uint8(10), s.Estimate(hot))
+
+	// Counters saturate.
+	for i := 0; i < 29; i++ {
+		s.Increment(cold)
+	}
+	assert.Equal(t, uint8(sketchMaxCount), s.Estimate(cold))
+	assert.Equal(t, uint8(10), s.Estimate(hot))
+
+	// Reaching the sample size halves the counters.
+	s.Increment(cold)
+	assert.Equal(t, uint8(5), s.Estimate(hot))
+	assert.Equal(t, uint8(7), s.Estimate(cold))
+	assert.Equal(t, uint64(20), s.additions)
+}
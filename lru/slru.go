@@ -0,0 +1,75 @@
+package lru
+
+// DefaultProtectedRatio is the share of the capacity reserved for the protected segment of SLRU.
+const DefaultProtectedRatio = 0.8
+
+// Segments of the SLRU policy.
+const (
+	slruProbation segment = iota
+	slruProtected
+)
+
+// slru is the Segmented LRU policy.
+// New keys enter the probationary segment and are promoted to the protected segment when they are used again,
+// the protected segment's least recently used keys are demoted back to probation when it's full.
+// Keys are evicted from probation first, so keys which are used once can't push out the ones which are used often.
+type slru[K comparable] struct {
+	segments[K]
+	maxProtected uint64
+}
+
+// NewSLRU returns an SLRU policy for a cache with the given capacity.
+// protectedRatio is the share of the capacity reserved for the protected segment, it's clamped to [0, 1].
+func NewSLRU[K comparable](capacity uint64, protectedRatio float64) Policy[K] {
+	if protectedRatio < 0 {
+		protectedRatio = 0
+	} else if protectedRatio > 1 {
+		protectedRatio = 1
+	}
+
+	return &slru[K]{
+		segments:     newSegments[K](2),
+		maxProtected: uint64(float64(capacity) * protectedRatio),
+	}
+}
+
+// Add implements Policy interface.
+func (p *slru[K]) Add(key K) {
+	p.push(key, slruProbation)
+}
+
+// Access implements Policy interface.
+func (p *slru[K]) Access(key K) {
+	node, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+
+	if p.maxProtected == 0 {
+		p.move(node, slruProbation)
+		return
+	}
+
+	p.move(node, slruProtected)
+	for p.size(slruProtected) > p.maxProtected {
+		p.move(p.head(slruProtected), slruProbation)
+	}
+}
+
+// Remove implements Policy interface.
+func (p *slru[K]) Remove(key K) {
+	p.remove(key)
+}
+
+// Evict implements Policy interface.
+func (p *slru[K]) Evict() (K, bool) {
+	if node := p.head(slruProbation); node != nil {
+		return p.pop(node)
+	}
+	return p.pop(p.head(slruProtected))
+}
+
+// Keys implements Policy interface.
+func (p *slru[K]) Keys() []K {
+	return p.keys(slruProbation, slruProtected)
+}
@@ -0,0 +1,60 @@
+package lru
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSLRU(t *testing.T) {
+	assert.Equal(t, uint64(8), NewSLRU[string](10, DefaultProtectedRatio).(*slru[string]).maxProtected)
+	assert.Equal(t, uint64(5), NewSLRU[string](10, 0.5).(*slru[string]).maxProtected)
+
+	// The ratio is clamped.
+	assert.Equal(t, uint64(0), NewSLRU[string](10, -1).(*slru[string]).maxProtected)
+	assert.Equal(t, uint64(10), NewSLRU[string](10, 2).(*slru[string]).maxProtected)
+}
+
+func TestSLRU(t *testing.T) {
+	p := NewSLRU[string](4, 0.5).(*slru[string])
+
+	_, ok := p.Evict()
+	assert.False(t, ok)
+
+	p.Add("1")
+	p.Add("2")
+	p.Add("3")
+	p.Add("4")
+
+	// Used keys are promoted to protected.
+	p.Access("1")
+	p.Access("2")
+	p.Access("not_found")
+	assert.Equal(t, []string{"3", "4"}, p.keys(slruProbation))
+	assert.Equal(t, []string{"1", "2"}, p.keys(slruProtected))
+
+	// The protected's least recently used key is demoted back to probation.
+	p.Access("3")
+	assert.Equal(t, []string{"4", "1"}, p.keys(slruProbation))
+	assert.Equal(t, []string{"2", "3"}, p.keys(slruProtected))
+
+	p.Access("2")
+	assert.Equal(t, []string{"3", "2"}, p.keys(slruProtected))
+
+	p.Remove("1")
+	p.Remove("not_found")
+
+	// Probation is evicted from first.
+	assert.Equal(t, []string{"4", "3", "2"}, evictAll[string](p))
+}
+
+func TestSLRUWithoutProtected(t *testing.T) {
+	p := NewSLRU[string](4, 0)
+
+	p.Add("1")
+	p.Add("2")
+	p.Access("1")
+
+	// It's a plain LRU.
+	assert.Equal(t, []string{"2", "1"}, evictAll(p))
+}
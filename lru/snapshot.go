@@ -0,0 +1,123 @@
+package lru
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// snapshotVersion is the version of the snapshot format, snapshots of other versions can't be restored.
+const snapshotVersion = 1
+
+type (
+	// snapshotHeader is the first JSON value of a snapshot.
+	snapshotHeader struct {
+		Version int `json:"version"`
+	}
+
+	// snapshotEntry is a key-value of a snapshot, it follows the header as a JSON value of its own.
+	snapshotEntry[K comparable, V any] struct {
+		Key       K          `json:"key"`
+		Value     V          `json:"value"`
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	}
+)
+
+// Snapshot writes a point-in-time copy of the cache's keys, values and expiration times to w as JSON.
+// The keys of each shard are written in eviction order, so Restore preserves their recency.
+// Expired keys are skipped.
+func (c *Cache[K, V]) Snapshot(ctx context.Context, w io.Writer) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(snapshotHeader{Version: snapshotVersion}); err != nil {
+		return err
+	}
+
+	for _, se := range c.snapshot() {
+		if err := enc.Encode(se); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// snapshot copies the entries of all shards at once, the shards are locked until all of them are copied.
+func (c *Cache[K, V]) snapshot() []snapshotEntry[K, V] {
+	for _, s := range c.shards {
+		s.m.RLock()
+		defer s.m.RUnlock()
+	}
+
+	size := 0
+	for _, s := range c.shards {
+		size += len(s.storage)
+	}
+
+	now := c.now()
+	entries := make([]snapshotEntry[K, V], 0, size)
+
+	for _, s := range c.shards {
+		for _, key := range s.policy.Keys() {
+			e := s.storage[key]
+			if e.expired(now) {
+				continue
+			}
+
+			se := snapshotEntry[K, V]{Key: key, Value: e.val}
+			if !e.expiresAt.IsZero() {
+				expiresAt := e.expiresAt
+				se.ExpiresAt = &expiresAt
+			}
+			entries = append(entries, se)
+		}
+	}
+
+	return entries
+}
+
+// Restore sets the keys of a snapshot written by Snapshot, on top of the keys which are already cached.
+// Keys are set in the snapshot's order, so the least recently used ones are evicted first if the cache is smaller.
+// They keep their expiration times, the keys which expired since the snapshot was taken are skipped.
+// Keys aren't written to the cache's store, and the keys read before an error are kept.
+func (c *Cache[K, V]) Restore(ctx context.Context, r io.Reader) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(r)
+
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return err
+	}
+	if header.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", header.Version)
+	}
+
+	for {
+		var se snapshotEntry[K, V]
+		if err := dec.Decode(&se); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var ttl time.Duration
+		if se.ExpiresAt != nil {
+			if ttl = se.ExpiresAt.Sub(c.now()); ttl <= 0 {
+				continue
+			}
+		}
+
+		if err := c.set(se.Key, se.Value, ttl); err != nil && !errors.Is(err, ErrTooLarge) {
+			return err
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package lru
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t, WithClock[string, int](clock.Now))
+
+	ctx := context.Background()
+
+	assert.NoError(t, cache.Set(ctx, "a", 1))
+	assert.NoError(t, cache.SetWithTTL(ctx, "b", 2, time.Minute))
+	assert.NoError(t, cache.SetWithTTL(ctx, "expired", 3, time.Second))
+	assert.NoError(t, cache.Set(ctx, "c", 3))
+
+	// a becomes the most recently used key.
+	_, err := cache.Get(ctx, "a")
+	assert.NoError(t, err)
+
+	clock.Advance(time.Second)
+
+	var buf bytes.Buffer
+	assert.NoError(t, cache.Snapshot(ctx, &buf))
+
+	assert.Equal(t, `{"version":1}
+{"key":"b","value":2,"expires_at":"`+time.Unix(1060, 0).Format(time.RFC3339Nano)+`"}
+{"key":"c","value":3}
+{"key":"a","value":1}
+`, buf.String())
+
+	// A smaller cache keeps the most recently used keys in the same order.
+	restored := newTestCache(t, WithCapacity[string, int](2), WithClock[string, int](clock.Now))
+	assert.NoError(t, restored.Restore(ctx, bytes.NewReader(buf.Bytes())))
+	assert.Equal(t, []string{"c", "a"}, restored.shards[0].policy.Keys())
+
+	// Keys keep their expiration times, and the ones which expired since are skipped.
+	restored = newTestCache(t, WithClock[string, int](clock.Now))
+	clock.Advance(30 * time.Second)
+	assert.NoError(t, restored.Restore(ctx, bytes.NewReader(buf.Bytes())))
+
+	item, err := restored.GetItem(ctx, "b")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, item.Value)
+	assert.Equal(t, time.Unix(1060, 0), item.ExpiresAt)
+
+	clock.Advance(30 * time.Second)
+	restored = newTestCache(t, WithClock[string, int](clock.Now))
+	assert.NoError(t, restored.Restore(ctx, bytes.NewReader(buf.Bytes())))
+	assert.Equal(t, []string{"c", "a"}, restored.shards[0].policy.Keys())
+}
+
+func TestSnapshotShards(t *testing.T) {
+	cache := newTestCache(t, WithShards[string, int](4))
+
+	ctx := context.Background()
+
+	for i, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		assert.NoError(t, cache.Set(ctx, key, i))
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, cache.Snapshot(ctx, &buf))
+
+	// The keys are restored into the same shards of a cache with the same number of shards.
+	restored := newTestCache(t, WithShards[string, int](4))
+	assert.NoError(t, restored.Restore(ctx, &buf))
+
+	for i := range cache.shards {
+		assert.Equal(t, cache.shards[i].policy.Keys(), restored.shards[i].policy.Keys())
+	}
+}
+
+func TestRestoreErrors(t *testing.T) {
+	cache := newTestCache(t)
+
+	ctx := context.Background()
+
+	assert.Error(t, cache.Restore(ctx, strings.NewReader("")))
+	assert.EqualError(t, cache.Restore(ctx, strings.NewReader(`{"version":2}`)), "unsupported snapshot version 2")
+
+	// The keys read before an error are kept.
+	assert.Error(t, cache.Restore(ctx, strings.NewReader(`{"version":1}
+{"key":"a","value":1}
+{"key":"b","value":"not a number"}
+`)))
+
+	val, err := cache.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, val)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	assert.ErrorIs(t, cache.Snapshot(cancelled, &bytes.Buffer{}), context.Canceled)
+	assert.ErrorIs(t, cache.Restore(cancelled, strings.NewReader(`{"version":1}`)), context.Canceled)
+}
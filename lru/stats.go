@@ -0,0 +1,72 @@
+package lru
+
+import "sync/atomic"
+
+type (
+	// Stats is a snapshot of the cache's counters.
+	Stats struct {
+		Hits   uint64 `json:"hits"`
+		Misses uint64 `json:"misses"`
+		// Sets counts every stored key-value, including overwrites.
+		Sets       uint64 `json:"sets"`
+		Overwrites uint64 `json:"overwrites"`
+		Deletes    uint64 `json:"deletes"`
+		// Evictions counts the keys evicted to respect the capacity or max bytes.
+		Evictions   uint64 `json:"evictions"`
+		Expirations uint64 `json:"expirations"`
+		// Size is the current number of keys, including expired keys which aren't removed yet.
+		Size  uint64 `json:"size"`
+		Bytes uint64 `json:"bytes"`
+	}
+
+	// counters are the cache's counters which are updated atomically.
+	counters struct {
+		hits        uint64
+		misses      uint64
+		sets        uint64
+		overwrites  uint64
+		deletes     uint64
+		evictions   uint64
+		expirations uint64
+
+		// versions is the last version given to an entry.
+		versions uint64
+	}
+)
+
+// HitRatio returns the ratio of hits to all lookups, it's zero if there was no lookup.
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(total)
+}
+
+// Stats returns a snapshot of the cache's counters.
+func (c *Cache[K, V]) Stats() Stats {
+	var size, bytes uint64
+	for _, s := range c.shards {
+		s.m.RLock()
+		size, bytes = size+uint64(len(s.storage)), bytes+s.bytes
+		s.m.RUnlock()
+	}
+
+	return Stats{
+		Hits:        atomic.LoadUint64(&c.counters.hits),
+		Misses:      atomic.LoadUint64(&c.counters.misses),
+		Sets:        atomic.LoadUint64(&c.counters.sets),
+		Overwrites:  atomic.LoadUint64(&c.counters.overwrites),
+		Deletes:     atomic.LoadUint64(&c.counters.deletes),
+		Evictions:   atomic.LoadUint64(&c.counters.evictions),
+		Expirations: atomic.LoadUint64(&c.counters.expirations),
+		Size:        size,
+		Bytes:       bytes,
+	}
+}
+
+// inc increments the counter atomically.
+func inc(counter *uint64) {
+	atomic.AddUint64(counter, 1)
+}
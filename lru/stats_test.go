@@ -0,0 +1,94 @@
+package lru
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	cache := newTestCache(t,
+		WithCapacity[string, int](2),
+		WithClock[string, int](clock.Now),
+		WithMaxBytes[string, int](100),
+		WithSizer(func(key string, val int) uint64 { return uint64(val) }),
+	)
+
+	assert.Equal(t, Stats{}, cache.Stats())
+
+	cache.set("first", 1, 0)
+	cache.set("first", 2, 0)
+	cache.set("second", 3, time.Second)
+
+	cache.get("first")
+	cache.get("first")
+	cache.get("not_found")
+
+	clock.Advance(time.Second)
+
+	// Expired keys are misses.
+	cache.get("second")
+
+	cache.set("third", 4, 0)
+	cache.set("fourth", 5, 0)
+
+	assert.NoError(t, cache.delete("third"))
+	assert.ErrorIs(t, cache.delete("third"), ErrNotFound)
+
+	stats := cache.Stats()
+	assert.Equal(t, Stats{
+		Hits:        2,
+		Misses:      2,
+		Sets:        5,
+		Overwrites:  1,
+		Deletes:     1,
+		Evictions:   1,
+		Expirations: 1,
+		Size:        1,
+		Bytes:       5,
+	}, stats)
+	assert.Equal(t, 0.5, stats.HitRatio())
+
+	cache.flush()
+
+	stats = cache.Stats()
+	assert.Zero(t, stats.Size)
+	assert.Zero(t, stats.Bytes)
+	assert.EqualValues(t, 5, stats.Sets)
+}
+
+func TestHitRatio(t *testing.T) {
+	assert.Zero(t, Stats{}.HitRatio())
+	assert.Equal(t, 0.75, Stats{Hits: 3, Misses: 1}.HitRatio())
+	assert.Equal(t, 1.0, Stats{Hits: 3}.HitRatio())
+}
+
+func TestStatsDataRace(t *testing.T) {
+	cache := newTestCache(t)
+
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	wg.Add(100)
+
+	for i := 0; i < 100; i++ {
+		go func() {
+			defer wg.Done()
+
+			cache.Set(ctx, "key", 1)
+			cache.Get(ctx, "key")
+			cache.Stats()
+		}()
+	}
+
+	wg.Wait()
+
+	stats := cache.Stats()
+	assert.EqualValues(t, 100, stats.Sets)
+	assert.EqualValues(t, 100, stats.Hits)
+	assert.EqualValues(t, 99, stats.Overwrites)
+}
@@ -0,0 +1,271 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type (
+	// Store is a backing store which the cache fronts, see WithStore.
+	// Its methods are called concurrently.
+	Store[K comparable, V any] interface {
+		// Load returns the key's value, or ErrNotFound if the key doesn't exist.
+		Load(ctx context.Context, key K) (V, error)
+		// Save stores or overwrites the key's value.
+		Save(ctx context.Context, key K, val V) error
+		// Delete removes the key, deleting a key which doesn't exist isn't an error.
+		Delete(ctx context.Context, key K) error
+	}
+
+	// writeBehind holds the writes which aren't saved to the store yet.
+	writeBehind[K comparable, V any] struct {
+		store Store[K, V]
+
+		m     sync.Mutex
+		dirty map[K]write[V]
+		// saving holds the writes of the batch which is being saved.
+		saving map[K]write[V]
+
+		// flushing serializes the flushes, so the writes of a key are saved in order.
+		flushing sync.Mutex
+		// kick asks the background loop to flush right away.
+		kick chan struct{}
+		// done is closed once the background loop has flushed for the last time.
+		done chan struct{}
+	}
+
+	// write is a pending write of a key.
+	write[V any] struct {
+		val     V
+		deleted bool
+	}
+
+	// keyLocks serializes the operations of each key.
+	keyLocks[K comparable] struct {
+		m     sync.Mutex
+		locks map[K]*keyLock
+	}
+
+	// keyLock is the lock of a key, it's removed once nobody holds or waits for it.
+	keyLock struct {
+		sync.Mutex
+		refs int
+	}
+)
+
+// newWriteBehind returns a new empty write behind for the store.
+func newWriteBehind[K comparable, V any](store Store[K, V]) *writeBehind[K, V] {
+	return &writeBehind[K, V]{
+		store: store,
+		dirty: make(map[K]write[V]),
+		kick:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+}
+
+// write sets or overwrites the key-value in both the cache and its store, caller must hold the key's lock.
+// Writes are saved to the store before the cache is touched, unless they are written behind.
+// It returns the evictions to be notified once the lock is released.
+func (c *Cache[K, V]) write(ctx context.Context, key K, val V, ttl time.Duration) ([]eviction[K, V], error) {
+	s, e := c.shard(key), c.newEntry(key, val)
+	if !s.fits(e) {
+		return nil, ErrTooLarge
+	}
+
+	if c.behind == nil {
+		if err := c.store.Save(ctx, key, val); err != nil {
+			return nil, err
+		}
+	}
+
+	evictions, err := s.set(key, e, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.behind != nil {
+		c.behind.add(key, write[V]{val: val})
+	}
+
+	return evictions, nil
+}
+
+// erase removes the key from both the cache and its store, caller must hold the key's lock.
+// The key is deleted from the store before the cache is touched, unless it's written behind.
+// It returns the key's eviction to be notified once the lock is released.
+func (c *Cache[K, V]) erase(ctx context.Context, key K) ([]eviction[K, V], error) {
+	if c.behind == nil {
+		if err := c.store.Delete(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+
+	evictions, err := c.shard(key).delete(key)
+
+	if c.behind != nil {
+		c.behind.add(key, write[V]{deleted: true})
+	}
+
+	// The key may only be in the store.
+	if errors.Is(err, ErrNotFound) {
+		return evictions, nil
+	}
+	return evictions, err
+}
+
+// loadStore loads the key from the store, the writes which aren't saved yet are loaded first.
+func (c *Cache[K, V]) loadStore(ctx context.Context, key K) (V, error) {
+	if c.behind != nil {
+		if w, ok := c.behind.pending(key); ok {
+			if w.deleted {
+				var zero V
+				return zero, ErrNotFound
+			}
+			return w.val, nil
+		}
+	}
+
+	return c.store.Load(ctx, key)
+}
+
+// Sync saves the writes which are written behind to the store, it's a no-op if writes aren't written behind.
+// It returns the first error of the store, the failed writes are retried on the next flush.
+func (c *Cache[K, V]) Sync(ctx context.Context) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	if c.behind == nil {
+		return nil
+	}
+
+	return c.behind.flush(ctx)
+}
+
+// flushBehind saves the writes which are written behind every interval, or right away once a dirty key is evicted.
+// It flushes for the last time once the cache is closed.
+func (c *Cache[K, V]) flushBehind(interval time.Duration) {
+	defer close(c.behind.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.behind.kick:
+		case <-c.stop:
+			_ = c.behind.flush(context.Background())
+			return
+		}
+
+		_ = c.behind.flush(context.Background())
+	}
+}
+
+// add records the key's latest write.
+func (w *writeBehind[K, V]) add(key K, wr write[V]) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.dirty[key] = wr
+}
+
+// pending returns the key's latest write which isn't saved yet.
+func (w *writeBehind[K, V]) pending(key K) (write[V], bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if wr, ok := w.dirty[key]; ok {
+		return wr, true
+	}
+
+	wr, ok := w.saving[key]
+	return wr, ok
+}
+
+// evicted asks for a flush if the evicted key isn't saved yet.
+func (w *writeBehind[K, V]) evicted(key K) {
+	w.m.Lock()
+	_, ok := w.dirty[key]
+	w.m.Unlock()
+
+	if ok {
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flush saves the dirty writes to the store as a batch.
+// Failed writes are kept for the next flush, unless the key is written again meanwhile.
+func (w *writeBehind[K, V]) flush(ctx context.Context) error {
+	w.flushing.Lock()
+	defer w.flushing.Unlock()
+
+	w.m.Lock()
+	batch := w.dirty
+	w.dirty, w.saving = make(map[K]write[V]), batch
+	w.m.Unlock()
+
+	var firstErr error
+	failed := make(map[K]write[V])
+
+	for key, wr := range batch {
+		var err error
+		if wr.deleted {
+			err = w.store.Delete(ctx, key)
+		} else {
+			err = w.store.Save(ctx, key, wr.val)
+		}
+
+		if err != nil {
+			failed[key] = wr
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	w.m.Lock()
+	for key, wr := range failed {
+		if _, ok := w.dirty[key]; !ok {
+			w.dirty[key] = wr
+		}
+	}
+	w.saving = nil
+	w.m.Unlock()
+
+	return firstErr
+}
+
+// lock locks the key and returns the function which unlocks it.
+func (l *keyLocks[K]) lock(key K) func() {
+	l.m.Lock()
+	if l.locks == nil {
+		l.locks = make(map[K]*keyLock)
+	}
+
+	kl, ok := l.locks[key]
+	if !ok {
+		kl = &keyLock{}
+		l.locks[key] = kl
+	}
+	kl.refs++
+	l.m.Unlock()
+
+	kl.Lock()
+
+	return func() {
+		kl.Unlock()
+
+		l.m.Lock()
+		if kl.refs--; kl.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.m.Unlock()
+	}
+}
@@ -0,0 +1,255 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyStore is a memory store which fails its writes while err is set and counts its loads.
+type flakyStore struct {
+	*MemoryStore[string, int]
+
+	m     sync.Mutex
+	err   error
+	loads int32
+}
+
+func (s *flakyStore) setErr(err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.err = err
+}
+
+func (s *flakyStore) getErr() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.err
+}
+
+func (s *flakyStore) Load(ctx context.Context, key string) (int, error) {
+	atomic.AddInt32(&s.loads, 1)
+	return s.MemoryStore.Load(ctx, key)
+}
+
+func (s *flakyStore) Save(ctx context.Context, key string, val int) error {
+	if err := s.getErr(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Save(ctx, key, val)
+}
+
+func (s *flakyStore) Delete(ctx context.Context, key string) error {
+	if err := s.getErr(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Delete(ctx, key)
+}
+
+func newFlakyStore() *flakyStore {
+	return &flakyStore{MemoryStore: NewMemoryStore[string, int]()}
+}
+
+func TestWriteThrough(t *testing.T) {
+	store := newFlakyStore()
+	cache := newTestCache(t, WithStore[string, int](store), WithCapacity[string, int](2))
+
+	ctx := context.Background()
+
+	// Writes are saved synchronously.
+	assert.NoError(t, cache.Set(ctx, "key", 1))
+
+	val, err := store.Load(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, val)
+
+	// Misses are loaded from the store and cached.
+	assert.NoError(t, store.Save(ctx, "stored", 2))
+
+	val, err = cache.Get(ctx, "stored")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, val)
+	assert.Contains(t, cache.shards[0].storage, "stored")
+
+	loads := store.loads
+	_, err = cache.Get(ctx, "stored")
+	assert.NoError(t, err)
+	assert.Equal(t, loads, store.loads)
+
+	_, err = cache.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Evicted keys are loaded again.
+	assert.NoError(t, cache.Set(ctx, "other", 3))
+
+	val, err = cache.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, val)
+
+	// Keys are deleted from both, even if they are only in the store.
+	assert.NoError(t, cache.Delete(ctx, "key"))
+	assert.NoError(t, cache.Delete(ctx, "stored"))
+
+	_, err = store.Load(ctx, "stored")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = cache.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Failed writes don't touch the cache.
+	store.setErr(errors.New("unavailable"))
+
+	assert.EqualError(t, cache.Set(ctx, "other", 4), "unavailable")
+	assert.EqualError(t, cache.Delete(ctx, "other"), "unavailable")
+
+	val, err = cache.Get(ctx, "other")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, val)
+}
+
+func TestWriteThroughTooLarge(t *testing.T) {
+	store := newFlakyStore()
+	cache := newTestCache(t,
+		WithStore[string, int](store),
+		WithMaxBytes[string, int](10),
+		WithSizer(func(key string, val int) uint64 { return uint64(val) }),
+	)
+
+	assert.ErrorIs(t, cache.Set(context.Background(), "key", 20), ErrTooLarge)
+	assert.Zero(t, store.Len())
+}
+
+func TestWriteThroughClearsNegatives(t *testing.T) {
+	store := newFlakyStore()
+	cache := newTestCache(t, WithStore[string, int](store), WithNegativeTTL[string, int](time.Hour), WithCapacity[string, int](1))
+
+	ctx := context.Background()
+
+	_, err := cache.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, cache.Set(ctx, "key", 1))
+	assert.NoError(t, cache.Set(ctx, "other", 2))
+
+	// The evicted key is loaded again instead of being reported as not found.
+	val, err := cache.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, val)
+}
+
+func TestWriteBehind(t *testing.T) {
+	store := newFlakyStore()
+	cache := newTestCache(t, WithStore[string, int](store), WithWriteBehind[string, int](time.Hour))
+
+	ctx := context.Background()
+
+	assert.NoError(t, cache.Set(ctx, "key", 1))
+	assert.NoError(t, cache.Set(ctx, "key", 2))
+	assert.NoError(t, cache.Set(ctx, "deleted", 3))
+	assert.NoError(t, cache.Delete(ctx, "deleted"))
+
+	// Writes aren't saved until they are flushed.
+	assert.Zero(t, store.Len())
+
+	val, err := cache.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, val)
+
+	_, err = cache.Get(ctx, "deleted")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Only the latest write of each key is saved.
+	assert.NoError(t, cache.Sync(ctx))
+
+	val, err = store.Load(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, val)
+	assert.Equal(t, 1, store.Len())
+
+	// Failed writes are retried.
+	store.setErr(errors.New("unavailable"))
+	assert.NoError(t, cache.Set(ctx, "key", 3))
+	assert.EqualError(t, cache.Sync(ctx), "unavailable")
+
+	store.setErr(nil)
+	assert.NoError(t, cache.Sync(ctx))
+
+	val, err = store.Load(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, val)
+
+	// Closing the cache saves the pending writes.
+	assert.NoError(t, cache.Set(ctx, "last", 4))
+	cache.Close()
+
+	val, err = store.Load(ctx, "last")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, val)
+}
+
+func TestWriteBehindEviction(t *testing.T) {
+	store := newFlakyStore()
+	cache := newTestCache(t, WithStore[string, int](store), WithWriteBehind[string, int](time.Hour), WithCapacity[string, int](1))
+
+	ctx := context.Background()
+
+	assert.NoError(t, cache.Set(ctx, "evicted", 1))
+	assert.NoError(t, cache.Set(ctx, "key", 2))
+
+	// The evicted key is readable before and after it's saved.
+	val, err := cache.Get(ctx, "evicted")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, val)
+
+	// Evicting a key which isn't saved yet flushes right away.
+	assert.Eventually(t, func() bool {
+		_, err := store.Load(ctx, "evicted")
+		return err == nil
+	}, time.Second, time.Millisecond)
+}
+
+func TestSyncWithoutWriteBehind(t *testing.T) {
+	cache := newTestCache(t)
+
+	assert.NoError(t, cache.Sync(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, cache.Sync(ctx), context.Canceled)
+}
+
+func TestKeyLocks(t *testing.T) {
+	var locks keyLocks[string]
+
+	unlock := locks.lock("key")
+
+	locked := make(chan struct{})
+	go func() {
+		defer locks.lock("key")()
+		close(locked)
+	}()
+
+	// Other keys aren't blocked.
+	locks.lock("other")()
+
+	select {
+	case <-locked:
+		t.Fatal("the key was locked twice")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	unlock()
+	<-locked
+
+	assert.Eventually(t, func() bool {
+		locks.m.Lock()
+		defer locks.m.Unlock()
+		return len(locks.locks) == 0
+	}, time.Second, time.Millisecond)
+}
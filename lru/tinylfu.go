@@ -0,0 +1,121 @@
+package lru
+
+import (
+	linkedlist "github.com/MojtabaArezoomand/lru_cache/internal/linked_list"
+)
+
+// Segments of the W-TinyLFU policy.
+const (
+	segmentWindow segment = iota
+	segmentProbation
+	segmentProtected
+)
+
+// tinyLFU is the W-TinyLFU policy.
+// New keys enter a small window LRU, keys leaving the window become candidates for the main SLRU.
+// A candidate only replaces the main's victim if the count-min sketch estimates it's used more often,
+// so one-off scans are evicted from the window without pushing out the hot keys.
+type tinyLFU[K comparable] struct {
+	segments[K]
+	sketch *countMinSketch
+
+	maxWindow    uint64
+	maxProtected uint64
+}
+
+// NewWTinyLFU returns a W-TinyLFU policy for a cache with the given capacity.
+// The window holds 1% of the capacity and the protected segment 80% of the rest.
+func NewWTinyLFU[K comparable](capacity uint64) Policy[K] {
+	maxWindow := capacity / 100
+	if maxWindow == 0 {
+		maxWindow = 1
+	}
+
+	var maxProtected uint64
+	if capacity > maxWindow {
+		maxProtected = (capacity - maxWindow) * 80 / 100
+	}
+
+	return &tinyLFU[K]{
+		segments:     newSegments[K](3),
+		sketch:       newCountMinSketch(capacity),
+		maxWindow:    maxWindow,
+		maxProtected: maxProtected,
+	}
+}
+
+// Add implements Policy interface.
+func (p *tinyLFU[K]) Add(key K) {
+	p.sketch.Increment(hashKey(key))
+	p.push(key, segmentWindow)
+
+	// The cache has room for the window's overflow, so it's moved to the main without admission.
+	for p.size(segmentWindow) > p.maxWindow {
+		p.move(p.head(segmentWindow), segmentProbation)
+	}
+}
+
+// Access implements Policy interface.
+func (p *tinyLFU[K]) Access(key K) {
+	node, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+
+	p.sketch.Increment(hashKey(key))
+
+	if node.GetVal() != segmentProbation {
+		p.move(node, node.GetVal())
+		return
+	}
+
+	p.move(node, segmentProtected)
+
+	// Demotes the protected's least recently used keys back to probation.
+	for p.size(segmentProtected) > p.maxProtected {
+		p.move(p.head(segmentProtected), segmentProbation)
+	}
+}
+
+// Remove implements Policy interface.
+func (p *tinyLFU[K]) Remove(key K) {
+	p.remove(key)
+}
+
+// Evict implements Policy interface.
+// If the window is full, its least recently used key is the candidate which has to leave it.
+// The candidate is admitted to the main by evicting the main's victim only if it's used more often.
+// The window's keys are evicted while the main is empty, even if the window isn't full.
+func (p *tinyLFU[K]) Evict() (K, bool) {
+	victim := p.victim()
+	candidate := p.head(segmentWindow)
+
+	if victim == nil {
+		return p.pop(candidate)
+	}
+	if p.size(segmentWindow) < p.maxWindow || candidate == nil {
+		return p.pop(victim)
+	}
+
+	if p.sketch.Estimate(hashKey(candidate.GetKey())) > p.sketch.Estimate(hashKey(victim.GetKey())) {
+		p.move(candidate, segmentProbation)
+		return p.pop(victim)
+	}
+
+	return p.pop(candidate)
+}
+
+// Keys implements Policy interface.
+// The order is approximate since the victim depends on the admission of the window's candidate,
+// keys of the probation come first, then the window's and the protected's.
+func (p *tinyLFU[K]) Keys() []K {
+	return p.keys(segmentProbation, segmentWindow, segmentProtected)
+}
+
+// victim returns the main's least recently used key, preferring the probation segment.
+func (p *tinyLFU[K]) victim() *linkedlist.Node[K, segment] {
+	if node := p.head(segmentProbation); node != nil {
+		return node
+	}
+	return p.head(segmentProtected)
+}
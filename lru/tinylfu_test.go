@@ -0,0 +1,151 @@
+package lru
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWTinyLFU(t *testing.T) {
+	p := NewWTinyLFU[string](1000).(*tinyLFU[string])
+	assert.Equal(t, uint64(10), p.maxWindow)
+	assert.Equal(t, uint64(792), p.maxProtected)
+
+	// The window always holds at least one key.
+	p = NewWTinyLFU[string](1).(*tinyLFU[string])
+	assert.Equal(t, uint64(1), p.maxWindow)
+	assert.Equal(t, uint64(0), p.maxProtected)
+}
+
+func TestWTinyLFU(t *testing.T) {
+	p := NewWTinyLFU[string](10).(*tinyLFU[string])
+
+	_, ok := p.Evict()
+	assert.False(t, ok)
+
+	// The window's overflow moves to probation.
+	p.Add("1")
+	p.Add("2")
+	p.Add("3")
+
+	assert.Equal(t, uint64(1), p.size(segmentWindow))
+	assert.Equal(t, uint64(2), p.size(segmentProbation))
+	assert.Equal(t, []string{"1", "2", "3"}, p.Keys())
+
+	// Accessed keys of probation are promoted to protected.
+	p.Access("1")
+	p.Access("3")
+	p.Access("not_found")
+
+	assert.Equal(t, uint64(1), p.size(segmentProtected))
+	assert.Equal(t, []string{"2", "3", "1"}, p.Keys())
+
+	p.Remove("2")
+	p.Remove("not_found")
+
+	assert.Equal(t, []string{"3", "1"}, p.Keys())
+
+	// The candidate isn't used more often than the victim, so it's evicted.
+	key, ok := p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "3", key)
+
+	key, ok = p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "1", key)
+
+	_, ok = p.Evict()
+	assert.False(t, ok)
+	assert.Empty(t, p.nodes)
+}
+
+func TestWTinyLFUAdmission(t *testing.T) {
+	p := NewWTinyLFU[string](2).(*tinyLFU[string])
+
+	p.Add("victim")
+	p.Add("candidate")
+	p.Access("candidate")
+
+	// The candidate is used more often, so it replaces the victim.
+	key, ok := p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "victim", key)
+	assert.Equal(t, []string{"candidate"}, p.Keys())
+	assert.Equal(t, uint64(1), p.size(segmentProbation))
+}
+
+func TestWTinyLFUProtectedDemotion(t *testing.T) {
+	p := NewWTinyLFU[string](12).(*tinyLFU[string])
+	assert.Equal(t, uint64(8), p.maxProtected)
+
+	for i := 0; i < 12; i++ {
+		p.Add(strconv.Itoa(i))
+	}
+	for i := 0; i < 11; i++ {
+		p.Access(strconv.Itoa(i))
+	}
+
+	// The least recently used keys of protected are demoted back to probation.
+	assert.Equal(t, uint64(8), p.size(segmentProtected))
+	assert.Equal(t, []string{"0", "1", "2", "11", "3", "4", "5", "6", "7", "8", "9", "10"}, p.Keys())
+}
+
+func TestWTinyLFUMaxBytes(t *testing.T) {
+	cache := newTestCache(t,
+		WithPolicy[string, int](PolicyWTinyLFU),
+		WithMaxBytes[string, int](10),
+		WithSizer(func(key string, val int) uint64 { return uint64(val) }),
+	)
+
+	// The keys stay in the window, which is far from full, so they're evicted from it.
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, cache.set(strconv.Itoa(i), 3, 0))
+		assert.LessOrEqual(t, cache.shards[0].bytes, uint64(10))
+	}
+
+	assert.EqualValues(t, 9, cache.shards[0].bytes)
+	assert.Equal(t, []string{"1", "2", "3"}, cache.shards[0].policy.Keys())
+}
+
+func TestWTinyLFUScanResistance(t *testing.T) {
+	for _, tc := range []struct {
+		policy    PolicyName
+		survivors int
+	}{
+		{policy: PolicyLRU, survivors: 0},
+		{policy: PolicyWTinyLFU, survivors: 50},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			cache := newTestCache(t, WithCapacity[string, int](100), WithPolicy[string, int](tc.policy))
+
+			ctx := context.Background()
+
+			for i := 0; i < 50; i++ {
+				key := "hot_" + strconv.Itoa(i)
+				assert.NoError(t, cache.Set(ctx, key, i))
+
+				for j := 0; j < 3; j++ {
+					_, err := cache.Get(ctx, key)
+					assert.NoError(t, err)
+				}
+			}
+
+			// A one-off scan which is much larger than the cache.
+			for i := 0; i < 1000; i++ {
+				assert.NoError(t, cache.Set(ctx, "scan_"+strconv.Itoa(i), i))
+			}
+
+			survivors := 0
+			for i := 0; i < 50; i++ {
+				if _, err := cache.Get(ctx, "hot_"+strconv.Itoa(i)); err == nil {
+					survivors++
+				}
+			}
+
+			assert.Equal(t, tc.survivors, survivors)
+			assert.Len(t, cache.shards[0].storage, 100)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package lru
+
+// Segments of the 2Q policy.
+const (
+	// twoQA1in holds the resident keys which are used once, in FIFO order.
+	twoQA1in segment = iota
+	// twoQAm holds the resident keys which are used again after leaving A1in, in LRU order.
+	twoQAm
+	// twoQA1out holds the ghosts of the keys evicted from A1in.
+	twoQA1out
+)
+
+// twoQ is the 2Q policy.
+// New keys enter the A1in FIFO queue and are remembered in the A1out ghost queue once evicted,
+// only keys which are added again while they are ghosts are promoted to the Am LRU list.
+type twoQ[K comparable] struct {
+	segments[K]
+	maxIn  uint64
+	maxOut uint64
+}
+
+// NewTwoQ returns a 2Q policy for a cache with the given capacity.
+// A1in holds 25% of the capacity and A1out remembers as many keys as 50% of the capacity.
+func NewTwoQ[K comparable](capacity uint64) Policy[K] {
+	maxIn, maxOut := capacity/4, capacity/2
+	if maxIn == 0 {
+		maxIn = 1
+	}
+	if maxOut == 0 {
+		maxOut = 1
+	}
+
+	return &twoQ[K]{segments: newSegments[K](3), maxIn: maxIn, maxOut: maxOut}
+}
+
+// Add implements Policy interface.
+func (p *twoQ[K]) Add(key K) {
+	if node, ok := p.nodes[key]; ok {
+		p.move(node, twoQAm)
+		return
+	}
+
+	p.push(key, twoQA1in)
+}
+
+// Access implements Policy interface.
+// Only keys of Am are reordered, A1in is a FIFO queue.
+func (p *twoQ[K]) Access(key K) {
+	if node, ok := p.nodes[key]; ok && node.GetVal() == twoQAm {
+		p.move(node, twoQAm)
+	}
+}
+
+// Remove implements Policy interface.
+func (p *twoQ[K]) Remove(key K) {
+	p.remove(key)
+}
+
+// Evict implements Policy interface.
+// It evicts from A1in once it's larger than its share of the capacity, otherwise from Am.
+func (p *twoQ[K]) Evict() (K, bool) {
+	node := p.head(twoQA1in)
+	if node == nil || (p.size(twoQA1in) <= p.maxIn && p.size(twoQAm) > 0) {
+		return p.pop(p.head(twoQAm))
+	}
+
+	p.move(node, twoQA1out)
+	for p.size(twoQA1out) > p.maxOut {
+		p.pop(p.head(twoQA1out))
+	}
+
+	return node.GetKey(), true
+}
+
+// Keys implements Policy interface.
+// The order is approximate since the victim depends on the size of A1in, keys of A1in come first.
+func (p *twoQ[K]) Keys() []K {
+	return p.keys(twoQA1in, twoQAm)
+}
@@ -0,0 +1,100 @@
+package lru
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTwoQ(t *testing.T) {
+	p := NewTwoQ[string](100).(*twoQ[string])
+	assert.Equal(t, uint64(25), p.maxIn)
+	assert.Equal(t, uint64(50), p.maxOut)
+
+	p = NewTwoQ[string](1).(*twoQ[string])
+	assert.Equal(t, uint64(1), p.maxIn)
+	assert.Equal(t, uint64(1), p.maxOut)
+}
+
+func TestTwoQ(t *testing.T) {
+	p := NewTwoQ[string](4).(*twoQ[string])
+
+	_, ok := p.Evict()
+	assert.False(t, ok)
+
+	p.Add("1")
+	p.Add("2")
+	p.Add("3")
+
+	// A1in is a FIFO queue.
+	p.Access("1")
+	p.Access("not_found")
+	assert.Equal(t, []string{"1", "2", "3"}, p.Keys())
+
+	// A1in is larger than its share, so its keys are evicted and remembered in A1out.
+	key, ok := p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "1", key)
+
+	key, ok = p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "2", key)
+	assert.Equal(t, []string{"1", "2"}, p.keys(twoQA1out))
+
+	// A1out is bounded.
+	p.Add("4")
+	p.Add("5")
+	p.Evict()
+	assert.Equal(t, []string{"2", "3"}, p.keys(twoQA1out))
+
+	// Keys which are added while they are ghosts are promoted to Am.
+	p.Add("2")
+	p.Add("3")
+	assert.Equal(t, []string{"2", "3"}, p.keys(twoQAm))
+	assert.Empty(t, p.keys(twoQA1out))
+
+	// Am is an LRU list.
+	p.Access("2")
+	assert.Equal(t, []string{"4", "5", "3", "2"}, p.Keys())
+
+	// A1in isn't larger than its share anymore, so Am is evicted from.
+	p.Remove("4")
+	p.Remove("not_found")
+
+	key, ok = p.Evict()
+	assert.True(t, ok)
+	assert.Equal(t, "3", key)
+
+	assert.Equal(t, []string{"2", "5"}, evictAll[string](p))
+}
+
+func TestTwoQScanResistance(t *testing.T) {
+	cache := newTestCache(t, WithCapacity[string, int](20), WithPolicy[string, int](PolicyTwoQ))
+
+	ctx := context.Background()
+
+	// The hot keys are promoted to Am after they are added again as ghosts.
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 10; i++ {
+			key := "hot_" + strconv.Itoa(i)
+			if _, err := cache.Get(ctx, key); err != nil {
+				assert.NoError(t, cache.Set(ctx, key, i))
+			}
+		}
+
+		for i := 0; i < 15; i++ {
+			assert.NoError(t, cache.Set(ctx, "filler_"+strconv.Itoa(round)+strconv.Itoa(i), i))
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, cache.Set(ctx, "scan_"+strconv.Itoa(i), i))
+	}
+
+	for i := 0; i < 10; i++ {
+		_, err := cache.Get(ctx, "hot_"+strconv.Itoa(i))
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,233 @@
+package wire
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/lru"
+)
+
+// ErrClientClosed is returned by the calls of a closed client.
+var ErrClientClosed = errors.New("wire: client is closed")
+
+// ResponseError is the error of a response with StatusBadRequest or StatusError.
+type ResponseError struct {
+	Status  Status
+	Message string
+}
+
+// Error implements error interface.
+func (e *ResponseError) Error() string {
+	return "wire: " + e.Message
+}
+
+// Client is a client of the binary protocol, it's safe for concurrent use.
+// Concurrent calls are pipelined over the client's single connection.
+type Client struct {
+	conn net.Conn
+
+	// wm serializes the writes of requests.
+	wm sync.Mutex
+	w  *bufio.Writer
+
+	m       sync.Mutex
+	lastID  uint32
+	pending map[uint32]chan Response
+	// err is set once the connection fails or the client is closed, the calls fail with it afterwards.
+	err error
+}
+
+// Dial connects to the server on the TCP address.
+func Dial(ctx context.Context, addr string) (*Client, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(conn), nil
+}
+
+// NewClient returns a new client which uses the connection, the connection is closed by Close.
+func NewClient(conn net.Conn) *Client {
+	c := Client{
+		conn:    conn,
+		w:       bufio.NewWriter(conn),
+		pending: make(map[uint32]chan Response),
+	}
+
+	go c.readResponses()
+
+	return &c
+}
+
+// Close closes the connection, the pending calls fail with ErrClientClosed.
+func (c *Client) Close() error {
+	c.fail(ErrClientClosed)
+	return nil
+}
+
+// Ping checks that the server answers.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.do(ctx, Request{Op: OpPing})
+	return err
+}
+
+// Get fetches the key's value, it returns lru.ErrNotFound if the key doesn't exist.
+// Values set over HTTP which aren't strings are returned as JSON.
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	return c.do(ctx, Request{Op: OpGet, Key: key})
+}
+
+// Set sets the key to the value with the cache's default ttl.
+func (c *Client) Set(ctx context.Context, key string, val []byte) error {
+	return c.SetWithTTL(ctx, key, val, DefaultTTL)
+}
+
+// SetWithTTL sets the key to the value with the ttl, a zero ttl means the key never expires.
+// It returns lru.ErrTooLarge if the value is larger than the cache's max bytes.
+func (c *Client) SetWithTTL(ctx context.Context, key string, val []byte, ttl time.Duration) error {
+	_, err := c.do(ctx, Request{Op: OpSet, TTL: ttl, Key: key, Value: val})
+	return err
+}
+
+// Delete removes the key, it returns lru.ErrNotFound if the key doesn't exist.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	_, err := c.do(ctx, Request{Op: OpDelete, Key: key})
+	return err
+}
+
+// Flush removes every key.
+func (c *Client) Flush(ctx context.Context) error {
+	_, err := c.do(ctx, Request{Op: OpFlush})
+	return err
+}
+
+// Stats fetches the cache's statistics.
+func (c *Client) Stats(ctx context.Context) (lru.Stats, error) {
+	var stats lru.Stats
+
+	body, err := c.do(ctx, Request{Op: OpStats})
+	if err != nil {
+		return stats, err
+	}
+
+	err = json.Unmarshal(body, &stats)
+	return stats, err
+}
+
+// do sends the request and waits for its response, it returns the response's body.
+// A call whose context is done returns without waiting, and its response is dropped once it arrives.
+func (c *Client) do(ctx context.Context, req Request) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Response, 1)
+
+	c.m.Lock()
+	if c.err != nil {
+		err := c.err
+		c.m.Unlock()
+		return nil, err
+	}
+	c.lastID++
+	req.ID = c.lastID
+	c.pending[req.ID] = ch
+	c.m.Unlock()
+
+	c.wm.Lock()
+	err := WriteRequest(c.w, req)
+	if err == nil {
+		err = c.w.Flush()
+	}
+	c.wm.Unlock()
+
+	if errors.Is(err, ErrMalformed) || errors.Is(err, ErrFrameTooLarge) {
+		// Nothing is written for requests which can't be encoded, so the connection can still be used.
+		c.forget(req.ID)
+		return nil, err
+	} else if err != nil {
+		c.fail(err)
+		return nil, err
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			c.m.Lock()
+			defer c.m.Unlock()
+			return nil, c.err
+		}
+		if err := resp.err(); err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	case <-ctx.Done():
+		c.forget(req.ID)
+		return nil, ctx.Err()
+	}
+}
+
+// forget stops waiting for the response of the request.
+func (c *Client) forget(id uint32) {
+	c.m.Lock()
+	delete(c.pending, id)
+	c.m.Unlock()
+}
+
+// readResponses passes the responses to their calls until the connection fails.
+func (c *Client) readResponses() {
+	r := bufio.NewReader(c.conn)
+
+	for {
+		resp, err := ReadResponse(r)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+
+		c.m.Lock()
+		ch := c.pending[resp.ID]
+		delete(c.pending, resp.ID)
+		c.m.Unlock()
+
+		if ch != nil {
+			ch <- resp
+		}
+	}
+}
+
+// fail closes the connection and fails the pending and later calls with the error, only the first error is kept.
+func (c *Client) fail(err error) {
+	c.m.Lock()
+	if c.err == nil {
+		c.err = err
+		for _, ch := range c.pending {
+			close(ch)
+		}
+		c.pending = nil
+	}
+	c.m.Unlock()
+
+	c.conn.Close()
+}
+
+// err returns the error of the response's status.
+func (resp Response) err() error {
+	switch resp.Status {
+	case StatusOK:
+		return nil
+	case StatusNotFound:
+		return lru.ErrNotFound
+	case StatusTooLarge:
+		return lru.ErrTooLarge
+	}
+
+	return &ResponseError{Status: resp.Status, Message: string(resp.Body)}
+}
@@ -0,0 +1,186 @@
+package wire
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MojtabaArezoomand/lru_cache/lru"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestClient returns a client connected to a fake server, which is passed the server's side of the connection.
+// Both sides are closed when the test finishes.
+func newTestClient(t *testing.T, serve func(conn net.Conn)) *Client {
+	server, conn := net.Pipe()
+	t.Cleanup(func() { server.Close() })
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serve(server)
+	}()
+
+	c := NewClient(conn)
+	t.Cleanup(func() {
+		c.Close()
+		server.Close()
+		<-done
+	})
+
+	return c
+}
+
+func TestClient(t *testing.T) {
+	c := newTestClient(t, func(conn net.Conn) {
+		r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+		for {
+			req, err := ReadRequest(r)
+			if err != nil {
+				return
+			}
+
+			resp := Response{ID: req.ID}
+			switch {
+			case req.Op == OpGet && req.Key == "key":
+				resp.Body = []byte("val")
+			case req.Op == OpGet:
+				resp.Status = StatusNotFound
+			case req.Op == OpSet && len(req.Value) > 3:
+				resp.Status = StatusTooLarge
+			case req.Op == OpStats:
+				resp.Body, _ = json.Marshal(lru.Stats{Hits: 2, Size: 1})
+			case req.Op == OpFlush:
+				resp.Status = StatusError
+				resp.Body = []byte("store is down")
+			}
+
+			WriteResponse(w, resp)
+			w.Flush()
+		}
+	})
+
+	ctx := context.Background()
+
+	assert.NoError(t, c.Ping(ctx))
+
+	val, err := c.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "val", string(val))
+
+	_, err = c.Get(ctx, "missing")
+	assert.ErrorIs(t, err, lru.ErrNotFound)
+
+	assert.NoError(t, c.Set(ctx, "key", []byte("val")))
+	assert.NoError(t, c.SetWithTTL(ctx, "key", []byte("val"), time.Minute))
+	assert.ErrorIs(t, c.Set(ctx, "key", []byte("large")), lru.ErrTooLarge)
+
+	stats, err := c.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, lru.Stats{Hits: 2, Size: 1}, stats)
+
+	err = c.Flush(ctx)
+	assert.Equal(t, &ResponseError{Status: StatusError, Message: "store is down"}, err)
+	assert.EqualError(t, err, "wire: store is down")
+
+	// Requests which can't be encoded aren't sent.
+	_, err = c.Get(ctx, string(make([]byte, MaxKeySize+1)))
+	assert.ErrorIs(t, err, ErrMalformed)
+	assert.NoError(t, c.Ping(ctx))
+
+	assert.NoError(t, c.Close())
+	assert.ErrorIs(t, c.Ping(ctx), ErrClientClosed)
+}
+
+func TestClientOutOfOrder(t *testing.T) {
+	const n = 10
+
+	// The server answers a batch of requests in the reverse order.
+	c := newTestClient(t, func(conn net.Conn) {
+		r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+
+		var reqs []Request
+		for len(reqs) < n {
+			req, err := ReadRequest(r)
+			if err != nil {
+				return
+			}
+			reqs = append(reqs, req)
+		}
+
+		for i := len(reqs) - 1; i >= 0; i-- {
+			WriteResponse(w, Response{ID: reqs[i].ID, Body: []byte(reqs[i].Key)})
+		}
+		w.Flush()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+
+			val, err := c.Get(context.Background(), key)
+			assert.NoError(t, err)
+			assert.Equal(t, key, string(val))
+		}(fmt.Sprint(i))
+	}
+	wg.Wait()
+}
+
+func TestClientContext(t *testing.T) {
+	received := make(chan Request)
+	reply := make(chan Response)
+
+	c := newTestClient(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		for {
+			req, err := ReadRequest(r)
+			if err != nil {
+				return
+			}
+			received <- req
+
+			if err := WriteResponse(conn, <-reply); err != nil {
+				return
+			}
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, c.Ping(ctx), context.Canceled)
+
+	// A call which times out returns without its response, which is dropped once it arrives.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error)
+	go func() { done <- c.Ping(ctx) }()
+
+	late := <-received
+	assert.ErrorIs(t, <-done, context.DeadlineExceeded)
+	reply <- Response{ID: late.ID, Status: StatusError}
+
+	go func() { done <- c.Ping(context.Background()) }()
+	req := <-received
+	reply <- Response{ID: req.ID}
+	assert.NoError(t, <-done)
+}
+
+func TestClientConnectionLost(t *testing.T) {
+	c := newTestClient(t, func(conn net.Conn) {
+		ReadRequest(bufio.NewReader(conn))
+		conn.Close()
+	})
+
+	// The pending call fails once the connection is lost, and so do later calls.
+	err := c.Ping(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, err, c.Ping(context.Background()))
+}
@@ -0,0 +1,226 @@
+// Package wire is a compact binary protocol of the cache over TCP, and its client.
+//
+// Every frame starts with its length as a big-endian uint32, which doesn't count the length itself,
+// followed by an ID which the client picks for a request and the server echoes in its response.
+// Clients can pipeline many requests over one connection, and the server sends the responses
+// as the requests finish, so they may arrive out of order.
+//
+// A request is:
+//
+//	length uint32 | id uint32 | op uint8 | ttl int64 | key length uint16 | key | value
+//
+// A response is:
+//
+//	length uint32 | id uint32 | status uint8 | body
+//
+// All integers are big-endian, and the ttl is in nanoseconds.
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Op is the operation of a request.
+type Op uint8
+
+// Ops.
+const (
+	// OpPing does nothing, it's answered with StatusOK.
+	OpPing Op = iota + 1
+	// OpGet fetches the key, the body of the response is its value.
+	OpGet
+	// OpSet sets the key to the value with the ttl.
+	OpSet
+	// OpDelete removes the key.
+	OpDelete
+	// OpFlush removes every key.
+	OpFlush
+	// OpStats fetches the cache's statistics, the body of the response is them as JSON.
+	OpStats
+)
+
+// Status is the result of a request.
+type Status uint8
+
+// Statuses, the body of a response with StatusBadRequest or StatusError is the error's message.
+const (
+	StatusOK Status = iota
+	StatusNotFound
+	StatusTooLarge
+	StatusBadRequest
+	StatusError
+)
+
+// Limits of a frame.
+const (
+	// MaxFrameSize is the maximum length of a frame, larger frames close the connection.
+	MaxFrameSize = 64 * 1024 * 1024
+	// MaxKeySize is the maximum length of a key.
+	MaxKeySize = math.MaxUint16
+
+	requestHeaderSize  = 4 + 1 + 8 + 2
+	responseHeaderSize = 4 + 1
+
+	// initialFrameSize is the initial capacity of a frame's buffer, which grows as the data arrives,
+	// so the length sent by a peer doesn't allocate more than the data it sends.
+	initialFrameSize = 64 * 1024
+)
+
+// DefaultTTL is the ttl of requests which set the key with the cache's default ttl.
+const DefaultTTL time.Duration = -1
+
+// Errors.
+var (
+	// ErrFrameTooLarge is returned when a frame is longer than MaxFrameSize.
+	ErrFrameTooLarge = errors.New("wire: frame is too large")
+	// ErrMalformed is returned when a frame can't be decoded, the frame is still read entirely.
+	ErrMalformed = errors.New("wire: malformed frame")
+)
+
+type (
+	// Request is a request of a client.
+	Request struct {
+		ID uint32
+		Op Op
+		// TTL is the ttl of OpSet, a negative ttl means the cache's default ttl.
+		TTL   time.Duration
+		Key   string
+		Value []byte
+	}
+
+	// Response is the response of a request, it has the request's ID.
+	Response struct {
+		ID     uint32
+		Status Status
+		Body   []byte
+	}
+)
+
+// WriteRequest writes the request as a frame.
+func WriteRequest(w io.Writer, req Request) error {
+	if len(req.Key) > MaxKeySize {
+		return fmt.Errorf("%w: key is longer than %d bytes", ErrMalformed, MaxKeySize)
+	}
+
+	size := requestHeaderSize + len(req.Key) + len(req.Value)
+	if size > MaxFrameSize {
+		return ErrFrameTooLarge
+	}
+
+	var header [4 + requestHeaderSize]byte
+	binary.BigEndian.PutUint32(header[0:], uint32(size))
+	binary.BigEndian.PutUint32(header[4:], req.ID)
+	header[8] = byte(req.Op)
+	binary.BigEndian.PutUint64(header[9:], uint64(req.TTL))
+	binary.BigEndian.PutUint16(header[17:], uint16(len(req.Key)))
+
+	return write(w, header[:], []byte(req.Key), req.Value)
+}
+
+// ReadRequest reads a request frame.
+// If the frame is malformed, the returned request still has the frame's ID when the frame is long enough to have one.
+func ReadRequest(r io.Reader) (Request, error) {
+	frame, err := readFrame(r)
+	if err != nil {
+		return Request{}, err
+	}
+
+	var req Request
+	if len(frame) >= 4 {
+		req.ID = binary.BigEndian.Uint32(frame)
+	}
+	if len(frame) < requestHeaderSize {
+		return req, fmt.Errorf("%w: request is shorter than its header", ErrMalformed)
+	}
+
+	req.Op = Op(frame[4])
+	req.TTL = time.Duration(binary.BigEndian.Uint64(frame[5:]))
+
+	keySize := int(binary.BigEndian.Uint16(frame[13:]))
+	rest := frame[requestHeaderSize:]
+	if keySize > len(rest) {
+		return req, fmt.Errorf("%w: key is longer than the request", ErrMalformed)
+	}
+
+	req.Key = string(rest[:keySize])
+	req.Value = rest[keySize:]
+
+	return req, nil
+}
+
+// WriteResponse writes the response as a frame.
+func WriteResponse(w io.Writer, resp Response) error {
+	size := responseHeaderSize + len(resp.Body)
+	if size > MaxFrameSize {
+		return ErrFrameTooLarge
+	}
+
+	var header [4 + responseHeaderSize]byte
+	binary.BigEndian.PutUint32(header[0:], uint32(size))
+	binary.BigEndian.PutUint32(header[4:], resp.ID)
+	header[8] = byte(resp.Status)
+
+	return write(w, header[:], resp.Body)
+}
+
+// ReadResponse reads a response frame.
+func ReadResponse(r io.Reader) (Response, error) {
+	frame, err := readFrame(r)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if len(frame) < responseHeaderSize {
+		return Response{}, fmt.Errorf("%w: response is shorter than its header", ErrMalformed)
+	}
+
+	return Response{
+		ID:     binary.BigEndian.Uint32(frame),
+		Status: Status(frame[4]),
+		Body:   frame[responseHeaderSize:],
+	}, nil
+}
+
+// readFrame reads a frame without its length.
+func readFrame(r io.Reader) ([]byte, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(length[:])
+	if size > MaxFrameSize {
+		return nil, ErrFrameTooLarge
+	}
+
+	var frame bytes.Buffer
+	if size < initialFrameSize {
+		frame.Grow(int(size))
+	} else {
+		frame.Grow(initialFrameSize)
+	}
+
+	if _, err := io.CopyN(&frame, r, int64(size)); errors.Is(err, io.EOF) {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+
+	return frame.Bytes(), nil
+}
+
+// write writes the parts in order.
+func write(w io.Writer, parts ...[]byte) error {
+	for _, part := range parts {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
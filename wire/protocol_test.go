@@ -0,0 +1,103 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+
+	reqs := []Request{
+		{ID: 1, Op: OpPing},
+		{ID: 2, Op: OpGet, Key: "key"},
+		{ID: 3, Op: OpSet, TTL: 90 * time.Second, Key: "key", Value: []byte("val")},
+		{ID: 4, Op: OpSet, TTL: DefaultTTL, Key: "key", Value: []byte{}},
+	}
+	for _, req := range reqs {
+		assert.NoError(t, WriteRequest(&buf, req))
+	}
+
+	for _, want := range reqs {
+		req, err := ReadRequest(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, want.ID, req.ID)
+		assert.Equal(t, want.Op, req.Op)
+		assert.Equal(t, want.TTL, req.TTL)
+		assert.Equal(t, want.Key, req.Key)
+		assert.Equal(t, string(want.Value), string(req.Value))
+	}
+
+	_, err := ReadRequest(&buf)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestResponseRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+
+	resps := []Response{
+		{ID: 1, Status: StatusOK, Body: []byte("val")},
+		{ID: 2, Status: StatusNotFound, Body: []byte{}},
+		{ID: 3, Status: StatusError, Body: []byte("store is down")},
+	}
+	for _, resp := range resps {
+		assert.NoError(t, WriteResponse(&buf, resp))
+	}
+
+	for _, want := range resps {
+		resp, err := ReadResponse(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, want, resp)
+	}
+}
+
+func TestMalformedFrames(t *testing.T) {
+	length := func(n uint32) []byte {
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, n)
+		return b
+	}
+	frame := func(body ...byte) *bytes.Reader {
+		return bytes.NewReader(append(length(uint32(len(body))), body...))
+	}
+
+	// A request which is shorter than its header keeps its ID.
+	req, err := ReadRequest(frame(0, 0, 0, 7, byte(OpGet)))
+	assert.ErrorIs(t, err, ErrMalformed)
+	assert.Equal(t, uint32(7), req.ID)
+
+	// A key which is longer than the request.
+	req, err = ReadRequest(frame(0, 0, 0, 8, byte(OpGet), 0, 0, 0, 0, 0, 0, 0, 0, 0, 5, 'k'))
+	assert.ErrorIs(t, err, ErrMalformed)
+	assert.Equal(t, uint32(8), req.ID)
+
+	_, err = ReadResponse(frame(0, 0, 0))
+	assert.ErrorIs(t, err, ErrMalformed)
+
+	// Frames are read entirely or not at all.
+	_, err = ReadResponse(bytes.NewReader([]byte{0, 0, 0, 9, 0, 0}))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	_, err = ReadRequest(bytes.NewReader(length(MaxFrameSize + 1)))
+	assert.ErrorIs(t, err, ErrFrameTooLarge)
+
+	// The length of a frame isn't allocated up front, only as much as the data which arrives.
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err = ReadRequest(bytes.NewReader(length(MaxFrameSize)))
+	runtime.ReadMemStats(&after)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1024*1024))
+
+	var buf bytes.Buffer
+	assert.ErrorIs(t, WriteRequest(&buf, Request{Op: OpGet, Key: strings.Repeat("k", MaxKeySize+1)}), ErrMalformed)
+	assert.ErrorIs(t, WriteRequest(&buf, Request{Op: OpSet, Key: "key", Value: make([]byte, MaxFrameSize)}), ErrFrameTooLarge)
+	assert.Zero(t, buf.Len())
+}